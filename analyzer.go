@@ -0,0 +1,154 @@
+package gpx
+
+import "time"
+
+// Analyzer serves the statistics of a GPX from the series computed once by
+// NewAnalyzer, for callers showing many statistics at once. It is read-only:
+// it keeps its own copy of the track points, so later changes to the GPX
+// aren't seen, and its methods return copies of the series.
+type Analyzer struct {
+	opts      MovingOptions
+	points    []WayPoint
+	distances []float64       // kilometers from the start
+	speeds    []float64       // m/s between consecutive track points
+	gaps      []time.Duration // between consecutive track points
+	profile   []ProfilePoint
+	duration  float64
+}
+
+// NewAnalyzer returns an Analyzer of the GPX.
+func NewAnalyzer(g *GPX) *Analyzer {
+	return NewAnalyzerOptions(g, MovingOptions{})
+}
+
+// NewAnalyzerOptions is like NewAnalyzer with the given moving options. The
+// series are computed in a single pass over the track points, with every
+// distance computed and every timestamp parsed once.
+func NewAnalyzerOptions(g *GPX, opts MovingOptions) *Analyzer {
+	a := &Analyzer{
+		opts:      opts.withDefaults(),
+		points:    []WayPoint{},
+		distances: []float64{},
+		speeds:    []float64{},
+		gaps:      []time.Duration{},
+	}
+
+	var distance float64
+	var previous *WayPoint
+	var previousTime, firstTime, lastTime time.Time
+
+	g.WalkPoints(func(trackIdx, segIdx, pointIdx int, p *WayPoint) {
+		t := p.Time()
+
+		if pointIdx > 0 {
+			d := previous.Distance(p)
+			distance += d
+
+			var gap time.Duration
+			var speed float64
+
+			if !previousTime.IsZero() && !t.IsZero() && t.After(previousTime) {
+				gap = t.Sub(previousTime)
+				speed = d * 1000 / gap.Seconds()
+			}
+
+			a.speeds = append(a.speeds, speed)
+			a.gaps = append(a.gaps, gap)
+		}
+
+		if len(a.points) == 0 {
+			firstTime = t
+		}
+
+		a.points = append(a.points, *p)
+		a.distances = append(a.distances, distance)
+		previous, previousTime, lastTime = p, t, t
+	})
+
+	if len(a.points) > 1 && lastTime.After(firstTime) && !firstTime.IsZero() {
+		a.duration = lastTime.Sub(firstTime).Seconds()
+	}
+
+	a.points = clonePoints(a.points)
+	a.profile = elevationProfile(a.points, a.distances)
+
+	return a
+}
+
+// Points returns the track points of every track segment.
+func (a *Analyzer) Points() []WayPoint {
+	return clonePoints(a.points)
+}
+
+// Distance returns the total distance in kilometers, see GPX.Distance.
+func (a *Analyzer) Distance() float64 {
+	if len(a.distances) == 0 {
+		return 0
+	}
+
+	return a.distances[len(a.distances)-1]
+}
+
+// Duration returns the duration in seconds, see GPX.Duration.
+func (a *Analyzer) Duration() float64 {
+	return a.duration
+}
+
+// CumulativeDistances returns the distance in kilometers from the start of
+// every track point, see GPX.CumulativeDistances.
+func (a *Analyzer) CumulativeDistances() []float64 {
+	return append([]float64{}, a.distances...)
+}
+
+// Speeds returns the speed in m/s between every two consecutive track points
+// of a segment, see GPX.Speeds.
+func (a *Analyzer) Speeds() []float64 {
+	return append([]float64{}, a.speeds...)
+}
+
+// TimeGaps returns the time between every two consecutive track points of a
+// segment, in the order of Speeds, see GPX.TimeGaps.
+func (a *Analyzer) TimeGaps() []time.Duration {
+	return append([]time.Duration{}, a.gaps...)
+}
+
+// ElevationProfile returns the elevation profile, see GPX.ElevationProfile.
+func (a *Analyzer) ElevationProfile() []ProfilePoint {
+	return append([]ProfilePoint{}, a.profile...)
+}
+
+// AverageSpeed returns the average speed in km/h, see GPX.AverageSpeed.
+func (a *Analyzer) AverageSpeed() float64 {
+	if a.duration == 0 {
+		return 0
+	}
+
+	return a.Distance() / (a.duration / 3600)
+}
+
+// MaxSpeed returns the highest speed in km/h, see GPX.MaxSpeed.
+func (a *Analyzer) MaxSpeed() float64 {
+	var maxSpeed float64
+
+	for _, speed := range a.speeds {
+		if speed > maxSpeed {
+			maxSpeed = speed
+		}
+	}
+
+	return maxSpeed * 3.6
+}
+
+// MovingTime returns the time in seconds spent moving with the options of
+// the Analyzer, see GPX.MovingTimeOptions.
+func (a *Analyzer) MovingTime() float64 {
+	var movingTime float64
+
+	for i, gap := range a.gaps {
+		if gap > 0 && a.opts.isMoving(a.speeds[i], gap) {
+			movingTime += gap.Seconds()
+		}
+	}
+
+	return movingTime
+}
@@ -0,0 +1,70 @@
+package gpx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzer(t *testing.T) {
+	for _, path := range []string{testGPX, "_data/two-segments.gpx", "_data/garmin-tpx.gpx", "_data/missing-elevation.gpx", "_data/sparse-segments.gpx"} {
+		b := openGPX(path)
+		gpx, _ := ReadGPX(b)
+
+		a := NewAnalyzer(gpx)
+
+		assert.Equal(t, gpx.Points(), a.Points(), path)
+		assert.InDelta(t, gpx.Distance(), a.Distance(), 1e-12, path)
+		assert.Equal(t, gpx.Duration(), a.Duration(), path)
+		assert.Equal(t, gpx.CumulativeDistances(), a.CumulativeDistances(), path)
+		assert.Equal(t, gpx.Speeds(), a.Speeds(), path)
+		assert.Equal(t, gpx.TimeGaps(), a.TimeGaps(), path)
+		assert.Equal(t, gpx.ElevationProfile(), a.ElevationProfile(), path)
+		assert.InDelta(t, gpx.AverageSpeed(), a.AverageSpeed(), 1e-9, path)
+		assert.Equal(t, gpx.MaxSpeed(), a.MaxSpeed(), path)
+		assert.Equal(t, gpx.MovingTime(), a.MovingTime(), path)
+	}
+}
+
+func TestAnalyzerOptions(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	opts := MovingOptions{StoppedSpeed: 5}
+
+	assert.Equal(t, gpx.MovingTimeOptions(opts), NewAnalyzerOptions(gpx, opts).MovingTime())
+	assert.Equal(t, []time.Duration{30 * time.Second, 30 * time.Second, 30 * time.Second, 30 * time.Second}, NewAnalyzer(gpx).TimeGaps())
+}
+
+func TestAnalyzerReadOnly(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	a := NewAnalyzer(gpx)
+	distance := a.Distance()
+	points := a.Points()
+
+	gpx.Tracks[0].TrackSegments[0].TrackPoint[0].Latitude = 0
+	a.Points()[0].Latitude = 0
+	a.Speeds()[0] = 100
+	a.CumulativeDistances()[1] = 100
+
+	assert.Equal(t, distance, a.Distance())
+	assert.Equal(t, points, a.Points())
+	assert.NotEqual(t, 100.0, a.Speeds()[0])
+	assert.NotEqual(t, 100.0, a.CumulativeDistances()[1])
+}
+
+func TestAnalyzerEmpty(t *testing.T) {
+	a := NewAnalyzer(&GPX{})
+
+	assert.Empty(t, a.Points())
+	assert.Equal(t, 0.0, a.Distance())
+	assert.Equal(t, 0.0, a.Duration())
+	assert.Empty(t, a.Speeds())
+	assert.Empty(t, a.TimeGaps())
+	assert.Equal(t, 0.0, a.AverageSpeed())
+	assert.Equal(t, 0.0, a.MaxSpeed())
+	assert.Equal(t, 0.0, a.MovingTime())
+}
@@ -0,0 +1,104 @@
+package gpx
+
+import "time"
+
+// Builder builds a GPX object programmatically, its methods return the
+// builder so calls can be chained:
+//
+//	g := NewBuilder().Creator("app").AddTrack("Morning Run").
+//		AddPoint(25.0, 121.5, 10, start).
+//		AddPoint(25.001, 121.5, 11, start.Add(time.Second)).
+//		Build()
+type Builder struct {
+	gpx *GPX
+}
+
+// NewBuilder returns a Builder of an empty GPX 1.1 object.
+func NewBuilder() *Builder {
+	return &Builder{gpx: &GPX{Version: "1.1"}}
+}
+
+// Creator sets the creator of the GPX.
+func (b *Builder) Creator(creator string) *Builder {
+	b.gpx.Creator = creator
+
+	return b
+}
+
+// Name sets the name in the metadata of the GPX.
+func (b *Builder) Name(name string) *Builder {
+	b.metadata().Name = name
+
+	return b
+}
+
+// Time sets the timestamp in the metadata of the GPX.
+func (b *Builder) Time(t time.Time) *Builder {
+	b.metadata().Timestamp = t.Format(time.RFC3339Nano)
+
+	return b
+}
+
+// AddWaypoint adds the waypoint to the GPX.
+func (b *Builder) AddWaypoint(w WayPoint) *Builder {
+	b.gpx.Waypoints = append(b.gpx.Waypoints, w)
+
+	return b
+}
+
+// AddTrack adds a track with the name and an empty segment, the following
+// points are added to it.
+func (b *Builder) AddTrack(name string) *Builder {
+	b.gpx.Tracks = append(b.gpx.Tracks, Track{Name: name, TrackSegments: []TrackSegment{{}}})
+
+	return b
+}
+
+// AddSegment adds an empty segment to the last track, the following points
+// are added to it. A track is added first when there is none.
+func (b *Builder) AddSegment() *Builder {
+	if len(b.gpx.Tracks) == 0 {
+		return b.AddTrack("")
+	}
+
+	track := &b.gpx.Tracks[len(b.gpx.Tracks)-1]
+	track.TrackSegments = append(track.TrackSegments, TrackSegment{})
+
+	return b
+}
+
+// AddPoint adds a track point to the last segment of the last track, they
+// are added first when there is none. The elevation is set even when it is
+// 0, and a zero t adds a point without timestamp.
+func (b *Builder) AddPoint(lat, lon, ele float64, t time.Time) *Builder {
+	point := WayPoint{Latitude: lat, Longitude: lon, Elevation: ele, ElevationSet: true}
+
+	if !t.IsZero() {
+		point.Timestamp = t.Format(time.RFC3339Nano)
+	}
+
+	return b.AddTrackPoint(point)
+}
+
+// AddTrackPoint is like AddPoint with a WayPoint, to set more than the
+// position, elevation and time.
+func (b *Builder) AddTrackPoint(w WayPoint) *Builder {
+	b.gpx.AppendPoint(w)
+
+	return b
+}
+
+// Build returns a copy of the built GPX, the builder can still be used
+// afterwards without affecting it.
+func (b *Builder) Build() *GPX {
+	return b.gpx.Clone()
+}
+
+// metadata returns the metadata of the GPX, created when there is none.
+func (b *Builder) metadata() *MetaData {
+	if b.gpx.Metadata == nil {
+		b.gpx.Metadata = &MetaData{}
+	}
+
+	return b.gpx.Metadata
+}
@@ -0,0 +1,61 @@
+package gpx
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	start := time.Date(2020, 5, 3, 7, 0, 0, 0, time.UTC)
+
+	gpx := NewBuilder().
+		Creator("app").
+		Name("Morning Run").
+		Time(start).
+		AddWaypoint(WayPoint{Latitude: 25, Longitude: 121.5, Name: "Start"}).
+		AddTrack("Run").
+		AddPoint(25.000, 121.5, 10, start).
+		AddPoint(25.001, 121.5, 11, start.Add(30*time.Second)).
+		AddSegment().
+		AddPoint(25.002, 121.5, 12, start.Add(time.Minute)).
+		AddPoint(25.003, 121.5, 13, time.Time{}).
+		Build()
+
+	assert.Equal(t, "app", gpx.Creator)
+	assert.Equal(t, "1.1", gpx.Version)
+	assert.Equal(t, "Morning Run", gpx.Metadata.Name)
+	assert.Equal(t, "2020-05-03T07:00:00Z", gpx.Metadata.Timestamp)
+	assert.Len(t, gpx.Waypoints, 1)
+	assert.Len(t, gpx.Tracks, 1)
+	assert.Equal(t, "Run", gpx.Tracks[0].Name)
+	assert.Len(t, gpx.Tracks[0].TrackSegments, 2)
+	assert.Len(t, gpx.Points(), 4)
+	assert.Equal(t, "2020-05-03T07:00:30Z", gpx.Points()[1].Timestamp)
+	assert.Equal(t, "", gpx.Points()[3].Timestamp)
+	assert.Equal(t, start.Add(time.Minute), gpx.Points()[2].Time())
+
+	var buf bytes.Buffer
+
+	assert.NoError(t, WriteGPX(&buf, gpx))
+	assert.Empty(t, gpx.Validate())
+
+	reread, err := ReadGPX(&buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, gpx.Points(), reread.Points())
+}
+
+func TestBuilderWithoutTrack(t *testing.T) {
+	builder := NewBuilder().AddPoint(25, 121.5, 10, time.Time{})
+	gpx := builder.Build()
+
+	builder.AddPoint(25.001, 121.5, 10, time.Time{})
+
+	assert.Len(t, gpx.Tracks, 1)
+	assert.Len(t, gpx.Points(), 1)
+	assert.Len(t, builder.Build().Points(), 2)
+	assert.Len(t, NewBuilder().AddSegment().AddSegment().Build().Tracks[0].TrackSegments, 2)
+}
@@ -0,0 +1,135 @@
+package gpx
+
+// Clone returns a deep copy of the GPX, sharing no slice or pointer with it,
+// so the copy can be modified without affecting the original.
+func (g *GPX) Clone() *GPX {
+	clone := *g
+	clone.Metadata = g.Metadata.clone()
+	clone.Waypoints = clonePoints(g.Waypoints)
+
+	if g.Namespaces != nil {
+		clone.Namespaces = make(map[string]string, len(g.Namespaces))
+
+		for prefix, namespace := range g.Namespaces {
+			clone.Namespaces[prefix] = namespace
+		}
+	}
+
+	if g.Routes != nil {
+		clone.Routes = make([]Route, len(g.Routes))
+
+		for i, route := range g.Routes {
+			route.Links = cloneLinks(route.Links)
+			route.Extensions = route.Extensions.clone()
+			route.RoutePoints = clonePoints(route.RoutePoints)
+			clone.Routes[i] = route
+		}
+	}
+
+	if g.Tracks != nil {
+		clone.Tracks = make([]Track, len(g.Tracks))
+
+		for i, track := range g.Tracks {
+			track.Links = cloneLinks(track.Links)
+			track.Extensions = track.Extensions.clone()
+
+			if track.TrackSegments != nil {
+				track.TrackSegments = make([]TrackSegment, len(g.Tracks[i].TrackSegments))
+
+				for j, segment := range g.Tracks[i].TrackSegments {
+					segment.TrackPoint = clonePoints(segment.TrackPoint)
+					segment.Extensions = segment.Extensions.clone()
+					track.TrackSegments[j] = segment
+				}
+			}
+
+			clone.Tracks[i] = track
+		}
+	}
+
+	return &clone
+}
+
+// clone returns a deep copy of the metadata.
+func (m *MetaData) clone() *MetaData {
+	if m == nil {
+		return nil
+	}
+
+	clone := *m
+	clone.Links = cloneLinks(m.Links)
+
+	if m.Author != nil {
+		author := *m.Author
+
+		if author.Email != nil {
+			email := *author.Email
+			author.Email = &email
+		}
+
+		if author.Link != nil {
+			link := *author.Link
+			author.Link = &link
+		}
+
+		clone.Author = &author
+	}
+
+	if m.Copyright != nil {
+		copyright := *m.Copyright
+		clone.Copyright = &copyright
+	}
+
+	if m.Bounds != nil {
+		bounds := *m.Bounds
+		clone.Bounds = &bounds
+	}
+
+	return &clone
+}
+
+// clone returns a deep copy of the extensions.
+func (e *Extensions) clone() *Extensions {
+	if e == nil {
+		return nil
+	}
+
+	return &Extensions{XML: append([]byte(nil), e.XML...)}
+}
+
+// clonePoints returns a deep copy of the points.
+func clonePoints(points []WayPoint) []WayPoint {
+	if points == nil {
+		return nil
+	}
+
+	clone := make([]WayPoint, len(points))
+
+	for i, point := range points {
+		point.Links = cloneLinks(point.Links)
+
+		if point.Extensions != nil {
+			extensions := *point.Extensions
+
+			if extensions.TrackPointExtensions != nil {
+				extension := *extensions.TrackPointExtensions
+				extensions.TrackPointExtensions = &extension
+			}
+
+			point.Extensions = &extensions
+		}
+
+		clone[i] = point
+	}
+
+	return clone
+}
+
+// cloneLinks returns a copy of the links.
+func cloneLinks(links []Link) []Link {
+	if links == nil {
+		return nil
+	}
+
+	return append([]Link(nil), links...)
+}
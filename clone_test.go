@@ -0,0 +1,69 @@
+package gpx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClone(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	clone := gpx.Clone()
+
+	assert.Equal(t, gpx, clone)
+
+	clone.Metadata.Timestamp = "2020-01-01T00:00:00Z"
+	clone.Tracks[0].Name = "Changed"
+	clone.Tracks[0].TrackSegments[0].TrackPoint[0].Latitude = 0
+	clone.Tracks[0].TrackSegments[0].TrackPoint[0].Extensions.TrackPointExtensions.HeartRate = 200
+	clone.Tracks[0].TrackSegments = append(clone.Tracks[0].TrackSegments, TrackSegment{})
+
+	assert.Equal(t, "2019-10-26T21:21:11Z", gpx.Metadata.Timestamp)
+	assert.Equal(t, "Strava Running Sample", gpx.Tracks[0].Name)
+	assert.Equal(t, 25.039374, gpx.Tracks[0].TrackSegments[0].TrackPoint[0].Latitude)
+	assert.Equal(t, 104, gpx.Tracks[0].TrackSegments[0].TrackPoint[0].Extensions.TrackPointExtensions.HeartRate)
+	assert.Len(t, gpx.Tracks[0].TrackSegments, 1)
+}
+
+func TestCloneMetadataAndExtensions(t *testing.T) {
+	b := openGPX("_data/metadata.gpx")
+	gpx, _ := ReadGPX(b)
+
+	gpx.Tracks[0].Extensions = &Extensions{XML: []byte("<color>red</color>")}
+	gpx.Tracks[0].Links = []Link{{URL: "https://example.com"}}
+	gpx.Routes = []Route{{RoutePoints: []WayPoint{{Links: []Link{{URL: "https://example.com"}}}}}}
+
+	clone := gpx.Clone()
+
+	assert.Equal(t, gpx, clone)
+
+	clone.Metadata.Author.Name = "Someone"
+	clone.Metadata.Author.Email.Domain = "example.org"
+	clone.Metadata.Author.Link.URL = "https://example.org"
+	clone.Metadata.Copyright.License = "https://example.org"
+	clone.Metadata.Links[0].Text = "Changed"
+	clone.Metadata.Bounds.MinLatitude = 0
+	clone.Tracks[0].Extensions.XML[1] = 'C'
+	clone.Tracks[0].Links[0].URL = "https://example.org"
+	clone.Routes[0].RoutePoints[0].Links[0].URL = "https://example.org"
+	clone.Namespaces["xsi"] = "urn:changed"
+
+	assert.Equal(t, "Peng Jie", gpx.Metadata.Author.Name)
+	assert.Equal(t, "example.com", gpx.Metadata.Author.Email.Domain)
+	assert.Equal(t, "https://github.com/neighborhood999", gpx.Metadata.Author.Link.URL)
+	assert.Equal(t, "https://opendatacommons.org/licenses/odbl/", gpx.Metadata.Copyright.License)
+	assert.Equal(t, "OpenStreetMap", gpx.Metadata.Links[0].Text)
+	assert.Equal(t, 25.0265, gpx.Metadata.Bounds.MinLatitude)
+	assert.Equal(t, "<color>red</color>", string(gpx.Tracks[0].Extensions.XML))
+	assert.Equal(t, "https://example.com", gpx.Tracks[0].Links[0].URL)
+	assert.Equal(t, "https://example.com", gpx.Routes[0].RoutePoints[0].Links[0].URL)
+	assert.Equal(t, XSINamespace, gpx.Namespaces["xsi"])
+}
+
+func TestCloneEmpty(t *testing.T) {
+	gpx := &GPX{}
+
+	assert.Equal(t, gpx, gpx.Clone())
+}
@@ -0,0 +1,373 @@
+package gpx
+
+import (
+	"math"
+	"time"
+)
+
+// Bearing returns the initial bearing (forward azimuth) from w to w2.
+// ref: https://www.movable-type.co.uk/scripts/latlong.html
+func (w *WayPoint) Bearing(w2 *WayPoint) Degrees {
+	lat1 := toRadians(w.Latitude)
+	lat2 := toRadians(w2.Latitude)
+	distanceLon := toRadians(w2.Longitude - w.Longitude)
+
+	y := math.Sin(distanceLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(distanceLon)
+
+	bearing := math.Mod(toDegrees(math.Atan2(y, x))+360, 360)
+
+	return Degrees(bearing)
+}
+
+// toDegrees converts radians to degrees.
+func toDegrees(radian float64) float64 {
+	return radian * 180.0 / math.Pi
+}
+
+// Distance3D returns two point distance in kilometers taking the elevation
+// change into account. A point without elevation (see HasElevation) is
+// treated as being at the same elevation as the other point, so it degrades
+// to Distance.
+func (w *WayPoint) Distance3D(w2 *WayPoint) float64 {
+	distance := w.Distance(w2)
+
+	if !w.HasElevation() || !w2.HasElevation() {
+		return distance
+	}
+
+	elevation := (w2.Elevation - w.Elevation) / 1000
+
+	return math.Sqrt(distance*distance + elevation*elevation)
+}
+
+// Distance3D returns total distance of every track segment in kilometers
+// taking the elevation change into account.
+func (g *GPX) Distance3D() float64 {
+	var totalDistance float64
+
+	for _, track := range g.Tracks {
+		for _, segment := range track.TrackSegments {
+			trackPoints := segment.TrackPoint
+
+			for i := 1; i < len(trackPoints); i++ {
+				totalDistance += trackPoints[i-1].Distance3D(&trackPoints[i])
+			}
+		}
+	}
+
+	return totalDistance
+}
+
+// DistanceApprox returns two point distance in kilometers by the
+// equirectangular approximation, which is cheaper than the haversine formula
+// of Distance. The error stays well under 0.1% for points less than 100km
+// apart, like consecutive track points, but grows with the distance and
+// near the poles.
+// ref: https://www.movable-type.co.uk/scripts/latlong.html
+func (w *WayPoint) DistanceApprox(w2 *WayPoint) float64 {
+	distanceLon := math.Remainder(w2.Longitude-w.Longitude, 360)
+	x := toRadians(distanceLon) * math.Cos(toRadians(w.Latitude+w2.Latitude)/2)
+	y := toRadians(w2.Latitude - w.Latitude)
+
+	return EARTHRADIUS * math.Sqrt(x*x+y*y)
+}
+
+// WGS-84 ellipsoid parameters used by DistanceVincenty.
+const (
+	wgs84SemiMajorAxis = 6378137.0
+	wgs84Flattening    = 1 / 298.257223563
+	wgs84SemiMinorAxis = wgs84SemiMajorAxis * (1 - wgs84Flattening)
+)
+
+// DistanceVincenty returns two point distance in kilometers on the WGS-84
+// ellipsoid by the Vincenty inverse formula, accurate to within a millimeter
+// where Distance assumes a spherical Earth. The formula doesn't converge for
+// nearly antipodal points, it then falls back to Distance.
+// ref: https://www.movable-type.co.uk/scripts/latlong-vincenty.html
+func (w *WayPoint) DistanceVincenty(w2 *WayPoint) float64 {
+	const a, b, f = wgs84SemiMajorAxis, wgs84SemiMinorAxis, wgs84Flattening
+
+	l := toRadians(w2.Longitude - w.Longitude)
+	u1 := math.Atan((1 - f) * math.Tan(toRadians(w.Latitude)))
+	u2 := math.Atan((1 - f) * math.Tan(toRadians(w2.Latitude)))
+	sinU1, cosU1 := math.Sin(u1), math.Cos(u1)
+	sinU2, cosU2 := math.Sin(u2), math.Cos(u2)
+
+	lambda := l
+
+	for i := 0; i < 200; i++ {
+		sinLambda, cosLambda := math.Sin(lambda), math.Cos(lambda)
+		sinSigma := math.Sqrt((cosU2*sinLambda)*(cosU2*sinLambda) +
+			(cosU1*sinU2-sinU1*cosU2*cosLambda)*(cosU1*sinU2-sinU1*cosU2*cosLambda))
+
+		if sinSigma == 0 {
+			return 0
+		}
+
+		cosSigma := sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma := math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cosSqAlpha := 1 - sinAlpha*sinAlpha
+		cos2SigmaM := 0.0
+
+		// Both points are on the equator otherwise.
+		if cosSqAlpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSqAlpha
+		}
+
+		c := f / 16 * cosSqAlpha * (4 + f*(4-3*cosSqAlpha))
+		previous := lambda
+		lambda = l + (1-c)*f*sinAlpha*
+			(sigma+c*sinSigma*(cos2SigmaM+c*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+
+		if math.Abs(lambda-previous) > 1e-12 {
+			continue
+		}
+
+		uSq := cosSqAlpha * (a*a - b*b) / (b * b)
+		bigA := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+		bigB := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+		deltaSigma := bigB * sinSigma * (cos2SigmaM + bigB/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+			bigB/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+
+		return b * bigA * (sigma - deltaSigma) / 1000
+	}
+
+	return w.Distance(w2)
+}
+
+// DistanceApprox returns total distance of every track segment in
+// kilometers like Distance, using DistanceApprox between the points. It is
+// faster for large tracks.
+func (g *GPX) DistanceApprox() float64 {
+	var totalDistance float64
+
+	for _, track := range g.Tracks {
+		for _, segment := range track.TrackSegments {
+			trackPoints := segment.TrackPoint
+
+			for i := 1; i < len(trackPoints); i++ {
+				totalDistance += trackPoints[i-1].DistanceApprox(&trackPoints[i])
+			}
+		}
+	}
+
+	return totalDistance
+}
+
+// Bounds returns the bounding box of every track point, route point and
+// waypoint. The ok value is false when the GPX has no point at all.
+func (g *GPX) Bounds() (minLat, minLon, maxLat, maxLon float64, ok bool) {
+	points := g.Points()
+
+	for _, route := range g.Routes {
+		points = append(points, route.RoutePoints...)
+	}
+
+	points = append(points, g.Waypoints...)
+
+	if len(points) == 0 {
+		return 0, 0, 0, 0, false
+	}
+
+	minLat, maxLat = points[0].Latitude, points[0].Latitude
+	minLon, maxLon = points[0].Longitude, points[0].Longitude
+
+	for _, point := range points[1:] {
+		minLat = math.Min(minLat, point.Latitude)
+		maxLat = math.Max(maxLat, point.Latitude)
+		minLon = math.Min(minLon, point.Longitude)
+		maxLon = math.Max(maxLon, point.Longitude)
+	}
+
+	return minLat, minLon, maxLat, maxLon, true
+}
+
+// Destination returns the point reached by traveling distanceKm kilometers
+// from w along the great circle starting at the given bearing.
+// ref: https://www.movable-type.co.uk/scripts/latlong.html
+func (w *WayPoint) Destination(bearing Degrees, distanceKm float64) Point {
+	lat1 := toRadians(w.Latitude)
+	lon1 := toRadians(w.Longitude)
+	theta := toRadians(float64(bearing))
+	delta := distanceKm / EARTHRADIUS
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(theta))
+	lon2 := lon1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(lat1), math.Cos(delta)-math.Sin(lat1)*math.Sin(lat2))
+
+	return Point{
+		Latitude:  toDegrees(lat2),
+		Longitude: math.Mod(toDegrees(lon2)+540, 360) - 180,
+	}
+}
+
+// MidpointTo returns the point halfway between w and w2 along their great
+// circle path.
+// ref: https://www.movable-type.co.uk/scripts/latlong.html
+func (w *WayPoint) MidpointTo(w2 *WayPoint) Point {
+	lat1 := toRadians(w.Latitude)
+	lon1 := toRadians(w.Longitude)
+	lat2 := toRadians(w2.Latitude)
+	distanceLon := toRadians(w2.Longitude - w.Longitude)
+
+	bx := math.Cos(lat2) * math.Cos(distanceLon)
+	by := math.Cos(lat2) * math.Sin(distanceLon)
+
+	lat := math.Atan2(math.Sin(lat1)+math.Sin(lat2), math.Sqrt((math.Cos(lat1)+bx)*(math.Cos(lat1)+bx)+by*by))
+	lon := lon1 + math.Atan2(by, math.Cos(lat1)+bx)
+
+	return Point{
+		Latitude:  toDegrees(lat),
+		Longitude: math.Mod(toDegrees(lon)+540, 360) - 180,
+	}
+}
+
+// Centroid returns the average position of the track points, the average of
+// their unit vectors in 3D Cartesian coordinates projected back on the
+// sphere, so tracks crossing the antimeridian or close to a pole are
+// averaged correctly. It returns a zero Point when there is no track point.
+func (g *GPX) Centroid() Point {
+	var x, y, z float64
+
+	for _, point := range g.Points() {
+		lat := toRadians(point.Latitude)
+		lon := toRadians(point.Longitude)
+
+		x += math.Cos(lat) * math.Cos(lon)
+		y += math.Cos(lat) * math.Sin(lon)
+		z += math.Sin(lat)
+	}
+
+	if x == 0 && y == 0 && z == 0 {
+		return Point{}
+	}
+
+	return Point{
+		Latitude:  toDegrees(math.Atan2(z, math.Hypot(x, y))),
+		Longitude: toDegrees(math.Atan2(y, x)),
+	}
+}
+
+// ToPoint returns the latitude and longitude of the WayPoint as a Point.
+func (w *WayPoint) ToPoint() Point {
+	return Point{Latitude: w.Latitude, Longitude: w.Longitude}
+}
+
+// NewWayPoint returns a WayPoint at the latitude and longitude of the Point.
+func NewWayPoint(p Point) WayPoint {
+	return WayPoint{Latitude: p.Latitude, Longitude: p.Longitude}
+}
+
+// NearestPoint returns the track point closest to p, its distance to p in
+// kilometers and its index in Points. The index is -1 when there is no
+// track point.
+func (g *GPX) NearestPoint(p Point) (WayPoint, float64, int) {
+	target := NewWayPoint(p)
+	nearest := -1
+	nearestDistance := 0.0
+	points := g.Points()
+
+	for i := range points {
+		if distance := target.Distance(&points[i]); nearest == -1 || distance < nearestDistance {
+			nearest = i
+			nearestDistance = distance
+		}
+	}
+
+	if nearest == -1 {
+		return WayPoint{}, 0, -1
+	}
+
+	return points[nearest], nearestDistance, nearest
+}
+
+// PointAtDistance returns the point at km kilometers from the start along the
+// track, interpolating its latitude, longitude, elevation and, when both
+// surrounding points have one, its timestamp. The distance is accumulated
+// like Distance, without connecting the segments. The ok value is false when
+// km is negative or exceeds the Distance.
+func (g *GPX) PointAtDistance(km float64) (WayPoint, bool) {
+	if km < 0 {
+		return WayPoint{}, false
+	}
+
+	var distance float64
+
+	for _, track := range g.Tracks {
+		for _, segment := range track.TrackSegments {
+			trackPoints := segment.TrackPoint
+
+			for i := range trackPoints {
+				if i == 0 {
+					if km == distance {
+						return trackPoints[i], true
+					}
+
+					continue
+				}
+
+				a, b := &trackPoints[i-1], &trackPoints[i]
+				step := a.Distance(b)
+
+				if km > distance+step {
+					distance += step
+					continue
+				}
+
+				var ratio float64
+
+				if step > 0 {
+					ratio = (km - distance) / step
+				}
+
+				var t time.Time
+
+				if start, end := a.Time(), b.Time(); !start.IsZero() && !end.IsZero() {
+					t = start.Add(time.Duration(ratio * float64(end.Sub(start))))
+				}
+
+				return interpolate(a, b, ratio, t), true
+			}
+		}
+	}
+
+	return WayPoint{}, false
+}
+
+// PointsInPolygon returns the track points inside the polygon, by ray
+// casting with the longitudes as x and the latitudes as y. The polygon is
+// closed implicitly between its last and first vertices, and the points on
+// its edges may be counted either way.
+func (g *GPX) PointsInPolygon(poly []Point) []WayPoint {
+	inside := []WayPoint{}
+
+	if len(poly) < 3 {
+		return inside
+	}
+
+	for _, point := range g.Points() {
+		if inPolygon(point.Latitude, point.Longitude, poly) {
+			inside = append(inside, point)
+		}
+	}
+
+	return inside
+}
+
+// inPolygon reports whether the position is inside the polygon, counting
+// the edges crossed by a ray cast from it towards increasing longitudes.
+func inPolygon(lat, lon float64, poly []Point) bool {
+	inside := false
+
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+
+		if (a.Latitude > lat) != (b.Latitude > lat) &&
+			lon < (b.Longitude-a.Longitude)*(lat-a.Latitude)/(b.Latitude-a.Latitude)+a.Longitude {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
@@ -0,0 +1,346 @@
+package gpx
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBearing(t *testing.T) {
+	origin := WayPoint{Latitude: 0, Longitude: 0}
+	north := WayPoint{Latitude: 1, Longitude: 0}
+	east := WayPoint{Latitude: 0, Longitude: 1}
+	south := WayPoint{Latitude: -1, Longitude: 0}
+	west := WayPoint{Latitude: 0, Longitude: -1}
+
+	assert.InDelta(t, 0.0, float64(origin.Bearing(&north)), 1e-9)
+	assert.InDelta(t, 90.0, float64(origin.Bearing(&east)), 1e-9)
+	assert.InDelta(t, 180.0, float64(origin.Bearing(&south)), 1e-9)
+	assert.InDelta(t, 270.0, float64(origin.Bearing(&west)), 1e-9)
+}
+
+func TestBearingKnownValue(t *testing.T) {
+	// Baghdad to Osaka, ref: https://www.movable-type.co.uk/scripts/latlong.html
+	baghdad := WayPoint{Latitude: 35, Longitude: 45}
+	osaka := WayPoint{Latitude: 35, Longitude: 135}
+
+	assert.InDelta(t, 60.16, float64(baghdad.Bearing(&osaka)), 0.01)
+}
+
+func TestToDegrees(t *testing.T) {
+	assert.Equal(t, 180.0, toDegrees(math.Pi))
+}
+
+func TestDistance3D(t *testing.T) {
+	start := WayPoint{Latitude: 25, Longitude: 121.5, Elevation: 100}
+	end := WayPoint{Latitude: 25.001, Longitude: 121.5, Elevation: 200}
+
+	horizontal := start.Distance(&end)
+	expected := math.Sqrt(horizontal*horizontal + 0.1*0.1)
+
+	assert.InDelta(t, expected, start.Distance3D(&end), 1e-12)
+	assert.Greater(t, start.Distance3D(&end), horizontal)
+}
+
+func TestDistance3DWithoutElevationChange(t *testing.T) {
+	start := WayPoint{Latitude: 25, Longitude: 121.5, Elevation: 100}
+	flat := WayPoint{Latitude: 25.001, Longitude: 121.5, Elevation: 100}
+	missing := WayPoint{Latitude: 25.001, Longitude: 121.5}
+
+	assert.Equal(t, start.Distance(&flat), start.Distance3D(&flat))
+	assert.Equal(t, start.Distance(&missing), start.Distance3D(&missing))
+}
+
+func TestGPXDistance3D(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Greater(t, gpx.Distance3D(), gpx.Distance())
+	assert.InDelta(t, gpx.Distance(), gpx.Distance3D(), 1e-4)
+}
+
+func TestBounds(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	minLat, minLon, maxLat, maxLon, ok := gpx.Bounds()
+
+	assert.True(t, ok)
+	assert.Equal(t, 25.038871, minLat)
+	assert.Equal(t, 121.516609, minLon)
+	assert.Equal(t, 25.039374, maxLat)
+	assert.Equal(t, 121.517575, maxLon)
+
+	for _, point := range gpx.Points() {
+		assert.True(t, point.Latitude >= minLat && point.Latitude <= maxLat)
+		assert.True(t, point.Longitude >= minLon && point.Longitude <= maxLon)
+	}
+}
+
+func TestBoundsRoutesAndWaypoints(t *testing.T) {
+	b := openGPX("_data/route.gpx")
+	gpx, _ := ReadGPX(b)
+
+	minLat, minLon, maxLat, maxLon, ok := gpx.Bounds()
+
+	assert.True(t, ok)
+	assert.Equal(t, []float64{25.05, 121.5, 25.059, 121.51}, []float64{minLat, minLon, maxLat, maxLon})
+
+	b = openGPX("_data/waypoints.gpx")
+	gpx, _ = ReadGPX(b)
+
+	minLat, minLon, maxLat, maxLon, ok = gpx.Bounds()
+
+	assert.True(t, ok)
+	assert.Equal(t, []float64{25.033964, 121.517, 25.102358, 121.564472}, []float64{minLat, minLon, maxLat, maxLon})
+}
+
+func TestBoundsEmpty(t *testing.T) {
+	_, _, _, _, ok := (&GPX{}).Bounds()
+
+	assert.False(t, ok)
+}
+
+func TestDestination(t *testing.T) {
+	start := WayPoint{Latitude: 25.039374, Longitude: 121.516609}
+
+	for _, bearing := range []Degrees{0, 45, 90, 135, 180, 270, 359} {
+		p := start.Destination(bearing, 12.5)
+		end := WayPoint{Latitude: p.Latitude, Longitude: p.Longitude}
+
+		assert.InDelta(t, 12.5, start.Distance(&end), 1e-9)
+		assert.InDelta(t, float64(bearing), float64(start.Bearing(&end)), 1e-6)
+	}
+}
+
+func TestDestinationKnownValue(t *testing.T) {
+	origin := WayPoint{Latitude: 0, Longitude: 0}
+	p := origin.Destination(90, EARTHRADIUS*math.Pi/2)
+
+	assert.InDelta(t, 0, p.Latitude, 1e-9)
+	assert.InDelta(t, 90, p.Longitude, 1e-9)
+}
+
+func TestDestinationAntimeridian(t *testing.T) {
+	start := WayPoint{Latitude: 0, Longitude: 179.9}
+	p := start.Destination(90, 22.239)
+
+	assert.InDelta(t, -179.9, p.Longitude, 1e-3)
+}
+
+func TestMidpointTo(t *testing.T) {
+	start := WayPoint{Latitude: 25.0, Longitude: 121.5}
+	end := WayPoint{Latitude: 25.1, Longitude: 121.6}
+
+	p := start.MidpointTo(&end)
+	mid := WayPoint{Latitude: p.Latitude, Longitude: p.Longitude}
+
+	assert.InDelta(t, start.Distance(&mid), mid.Distance(&end), 1e-9)
+	assert.InDelta(t, start.Distance(&end)/2, start.Distance(&mid), 1e-9)
+}
+
+func TestMidpointToNearPole(t *testing.T) {
+	start := WayPoint{Latitude: 80, Longitude: 0}
+	end := WayPoint{Latitude: 80, Longitude: 180}
+
+	p := start.MidpointTo(&end)
+
+	// The great circle passes over the pole, the naive average is (80, 90).
+	assert.InDelta(t, 90, p.Latitude, 1e-9)
+
+	start = WayPoint{Latitude: 70, Longitude: -30}
+	end = WayPoint{Latitude: 70, Longitude: 60}
+	p = start.MidpointTo(&end)
+	mid := WayPoint{Latitude: p.Latitude, Longitude: p.Longitude}
+
+	assert.Greater(t, p.Latitude, 72.0)
+	assert.InDelta(t, 15, p.Longitude, 1e-9)
+	assert.InDelta(t, start.Distance(&mid), mid.Distance(&end), 1e-9)
+}
+
+func TestToPointAndNewWayPoint(t *testing.T) {
+	w := WayPoint{Latitude: 25.039374, Longitude: 121.516609, Elevation: 15.4, Name: "Start"}
+	p := w.ToPoint()
+
+	assert.Equal(t, Point{Latitude: 25.039374, Longitude: 121.516609}, p)
+	assert.Equal(t, WayPoint{Latitude: 25.039374, Longitude: 121.516609}, NewWayPoint(p))
+
+	end := NewWayPoint(w.Destination(90, 1))
+
+	assert.InDelta(t, 1, w.Distance(&end), 1e-9)
+}
+
+func TestNearestPoint(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	point, distance, index := gpx.NearestPoint(Point{Latitude: 25.0102, Longitude: 121.5001})
+	expected := WayPoint{Latitude: 25.0102, Longitude: 121.5001}
+
+	assert.Equal(t, 3, index)
+	assert.Equal(t, gpx.Tracks[0].TrackSegments[1].TrackPoint[0], point)
+	assert.InDelta(t, expected.Distance(&point), distance, 1e-12)
+
+	point, distance, index = gpx.NearestPoint(Point{Latitude: 25.002, Longitude: 121.5})
+
+	assert.Equal(t, 2, index)
+	assert.Equal(t, 25.002, point.Latitude)
+	assert.Equal(t, 0.0, distance)
+}
+
+func TestNearestPointEmpty(t *testing.T) {
+	point, distance, index := (&GPX{}).NearestPoint(Point{Latitude: 25, Longitude: 121})
+
+	assert.Equal(t, WayPoint{}, point)
+	assert.Equal(t, 0.0, distance)
+	assert.Equal(t, -1, index)
+}
+
+func TestPointAtDistance(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	points := gpx.Points()
+	half := points[0].Distance(&points[1]) / 2
+	point, ok := gpx.PointAtDistance(half)
+
+	assert.True(t, ok)
+	assert.InDelta(t, 25.0005, point.Latitude, 1e-9)
+	assert.Equal(t, 121.5, point.Longitude)
+	assert.InDelta(t, 10.5, point.Elevation, 1e-9)
+	assert.Equal(t, time.Date(2020, 5, 3, 7, 0, 15, 0, time.UTC), point.Time())
+
+	start, ok := gpx.PointAtDistance(0)
+
+	assert.True(t, ok)
+	assert.Equal(t, points[0], start)
+
+	end, ok := gpx.PointAtDistance(gpx.Distance())
+
+	assert.True(t, ok)
+	assert.InDelta(t, points[5].Latitude, end.Latitude, 1e-9)
+
+	_, ok = gpx.PointAtDistance(gpx.Distance() + 0.001)
+
+	assert.False(t, ok)
+
+	_, ok = gpx.PointAtDistance(-1)
+
+	assert.False(t, ok)
+
+	_, ok = (&GPX{}).PointAtDistance(0)
+
+	assert.False(t, ok)
+}
+
+func TestPointsInPolygon(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	square := []Point{
+		{Latitude: 24.9995, Longitude: 121.4995},
+		{Latitude: 24.9995, Longitude: 121.5005},
+		{Latitude: 25.0015, Longitude: 121.5005},
+		{Latitude: 25.0015, Longitude: 121.4995},
+	}
+	points := gpx.Points()
+
+	assert.Equal(t, points[0:2], gpx.PointsInPolygon(square))
+
+	triangle := []Point{
+		{Latitude: 25.0105, Longitude: 121.5},
+		{Latitude: 25.0125, Longitude: 121.4},
+		{Latitude: 25.0125, Longitude: 121.6},
+	}
+
+	assert.Equal(t, points[4:6], gpx.PointsInPolygon(triangle))
+	assert.Empty(t, gpx.PointsInPolygon(square[:2]))
+}
+
+func TestDistanceApprox(t *testing.T) {
+	w := WayPoint{Latitude: 25.039374, Longitude: 121.516609}
+	w2 := WayPoint{Latitude: 25.1, Longitude: 121.6}
+	east := WayPoint{Latitude: 0, Longitude: 179.9}
+	west := WayPoint{Latitude: 0, Longitude: -179.9}
+
+	assert.InDelta(t, w.Distance(&w2), w.DistanceApprox(&w2), w.Distance(&w2)*1e-4)
+	assert.InDelta(t, east.Distance(&west), east.DistanceApprox(&west), 1e-6)
+	assert.Equal(t, 0.0, w.DistanceApprox(&w))
+
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	assert.InDelta(t, gpx.Distance(), gpx.DistanceApprox(), gpx.Distance()*1e-4)
+}
+
+func TestDistanceVincenty(t *testing.T) {
+	// Flinders Peak to Buninyong, ref: Geoscience Australia.
+	flindersPeak := WayPoint{Latitude: -37.95103341666667, Longitude: 144.42486788888889}
+	buninyong := WayPoint{Latitude: -37.65282113888889, Longitude: 143.92649552777777}
+
+	assert.InDelta(t, 54.972271, flindersPeak.DistanceVincenty(&buninyong), 1e-6)
+	assert.InDelta(t, 54.972271, buninyong.DistanceVincenty(&flindersPeak), 1e-6)
+	assert.Equal(t, 0.0, flindersPeak.DistanceVincenty(&flindersPeak))
+
+	equator := WayPoint{Latitude: 0, Longitude: 0}
+	east := WayPoint{Latitude: 0, Longitude: 1}
+
+	assert.InDelta(t, 111.319491, equator.DistanceVincenty(&east), 1e-6)
+}
+
+func TestDistanceVincentyAntipodal(t *testing.T) {
+	w := WayPoint{Latitude: 0, Longitude: 0}
+	w2 := WayPoint{Latitude: 0.5, Longitude: 179.7}
+
+	assert.Equal(t, w.Distance(&w2), w.DistanceVincenty(&w2))
+}
+
+func BenchmarkDistance(b *testing.B) {
+	w := WayPoint{Latitude: 25.039374, Longitude: 121.516609}
+	w2 := WayPoint{Latitude: 25.039409, Longitude: 121.516542}
+
+	for i := 0; i < b.N; i++ {
+		w.Distance(&w2)
+	}
+}
+
+func BenchmarkDistanceApprox(b *testing.B) {
+	w := WayPoint{Latitude: 25.039374, Longitude: 121.516609}
+	w2 := WayPoint{Latitude: 25.039409, Longitude: 121.516542}
+
+	for i := 0; i < b.N; i++ {
+		w.DistanceApprox(&w2)
+	}
+}
+
+func TestCentroid(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25, Longitude: 121.5},
+		{Latitude: 25.002, Longitude: 121.5},
+	}, []WayPoint{
+		{Latitude: 25.001, Longitude: 121.502},
+	})
+
+	centroid := gpx.Centroid()
+
+	assert.InDelta(t, 25.001, centroid.Latitude, 1e-6)
+	assert.InDelta(t, 121.50067, centroid.Longitude, 1e-5)
+}
+
+func TestCentroidAntimeridian(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: -17, Longitude: 179.9},
+		{Latitude: -17, Longitude: -179.9},
+	})
+
+	centroid := gpx.Centroid()
+
+	assert.InDelta(t, -17.0, centroid.Latitude, 1e-4)
+	assert.InDelta(t, 180.0, math.Abs(centroid.Longitude), 1e-9)
+}
+
+func TestCentroidEmpty(t *testing.T) {
+	assert.Equal(t, Point{}, (&GPX{}).Centroid())
+}
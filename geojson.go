@@ -0,0 +1,250 @@
+package gpx
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// geoJSONFeatureCollection is the representation of a GeoJSON FeatureCollection.
+// ref: https://tools.ietf.org/html/rfc7946
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+// geoJSONFeature is the representation of a GeoJSON Feature.
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// geoJSONGeometry is the representation of a GeoJSON geometry object.
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// geoJSONObject is the representation of any GeoJSON object when reading,
+// only the members of its type are set.
+type geoJSONObject struct {
+	Type        string                 `json:"type"`
+	Features    []geoJSONObject        `json:"features"`
+	Geometry    *geoJSONObject         `json:"geometry"`
+	Geometries  []geoJSONObject        `json:"geometries"`
+	Coordinates json.RawMessage        `json:"coordinates"`
+	Properties  map[string]interface{} `json:"properties"`
+}
+
+// ToGeoJSON returns the GPX as a GeoJSON FeatureCollection.
+// Every track is a LineString Feature, or a MultiLineString Feature when it
+// has more than one segment, and every waypoint is a Point Feature.
+// Coordinates are [lon, lat, ele] when the track or waypoint has elevation,
+// [lon, lat] otherwise.
+func (g *GPX) ToGeoJSON() ([]byte, error) {
+	collection := geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: []geoJSONFeature{},
+	}
+
+	for _, track := range g.Tracks {
+		collection.Features = append(collection.Features, trackFeature(&track))
+	}
+
+	for _, waypoint := range g.Waypoints {
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type: "Feature",
+			Geometry: geoJSONGeometry{
+				Type:        "Point",
+				Coordinates: geoJSONPosition(&waypoint, waypoint.HasElevation()),
+			},
+			Properties: geoJSONProperties(waypoint.Name, waypoint.Description, waypoint.Type),
+		})
+	}
+
+	return json.Marshal(collection)
+}
+
+// trackFeature returns the track as a LineString or MultiLineString Feature.
+func trackFeature(track *Track) geoJSONFeature {
+	withElevation := false
+
+	for _, segment := range track.TrackSegments {
+		for _, point := range segment.TrackPoint {
+			if point.HasElevation() {
+				withElevation = true
+			}
+		}
+	}
+
+	lines := make([][][]float64, len(track.TrackSegments))
+
+	for i, segment := range track.TrackSegments {
+		lines[i] = make([][]float64, len(segment.TrackPoint))
+
+		for j := range segment.TrackPoint {
+			lines[i][j] = geoJSONPosition(&segment.TrackPoint[j], withElevation)
+		}
+	}
+
+	geometry := geoJSONGeometry{Type: "MultiLineString", Coordinates: lines}
+
+	if len(lines) == 1 {
+		geometry = geoJSONGeometry{Type: "LineString", Coordinates: lines[0]}
+	}
+
+	return geoJSONFeature{
+		Type:       "Feature",
+		Geometry:   geometry,
+		Properties: geoJSONProperties(track.Name, track.Description, track.Type),
+	}
+}
+
+// geoJSONPosition returns the point as a GeoJSON position.
+func geoJSONPosition(w *WayPoint, withElevation bool) []float64 {
+	if withElevation {
+		return []float64{w.Longitude, w.Latitude, w.Elevation}
+	}
+
+	return []float64{w.Longitude, w.Latitude}
+}
+
+// geoJSONProperties returns the non-empty name, description and type as properties.
+func geoJSONProperties(name, description, kind string) map[string]interface{} {
+	properties := map[string]interface{}{}
+
+	if name != "" {
+		properties["name"] = name
+	}
+
+	if description != "" {
+		properties["desc"] = description
+	}
+
+	if kind != "" {
+		properties["type"] = kind
+	}
+
+	return properties
+}
+
+// FromGeoJSON returns a GPX 1.1 object from a GeoJSON FeatureCollection,
+// Feature or bare geometry, the reverse of ToGeoJSON. Every LineString is a
+// track of one segment, every MultiLineString a track of one segment per
+// line, and every Point or MultiPoint position a waypoint. The name, desc and
+// type properties of a Feature are set on its tracks and waypoints, and the
+// third coordinate of a position is the elevation. Other geometries are
+// skipped.
+func FromGeoJSON(b []byte) (*GPX, error) {
+	var object geoJSONObject
+
+	if err := json.Unmarshal(b, &object); err != nil {
+		return nil, err
+	}
+
+	g := &GPX{Version: "1.1"}
+
+	if err := g.addGeoJSON(&object, nil); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// addGeoJSON adds the tracks and waypoints of the GeoJSON object with the
+// properties of its Feature to the GPX.
+func (g *GPX) addGeoJSON(object *geoJSONObject, properties map[string]interface{}) error {
+	name, _ := properties["name"].(string)
+	description, _ := properties["desc"].(string)
+	kind, _ := properties["type"].(string)
+
+	switch object.Type {
+	case "FeatureCollection":
+		for i := range object.Features {
+			if err := g.addGeoJSON(&object.Features[i], nil); err != nil {
+				return err
+			}
+		}
+	case "Feature":
+		if object.Geometry != nil {
+			return g.addGeoJSON(object.Geometry, object.Properties)
+		}
+	case "GeometryCollection":
+		for i := range object.Geometries {
+			if err := g.addGeoJSON(&object.Geometries[i], properties); err != nil {
+				return err
+			}
+		}
+	case "Point", "MultiPoint":
+		var positions [][]float64
+
+		if object.Type == "Point" {
+			positions = make([][]float64, 1)
+			if err := json.Unmarshal(object.Coordinates, &positions[0]); err != nil {
+				return err
+			}
+		} else if err := json.Unmarshal(object.Coordinates, &positions); err != nil {
+			return err
+		}
+
+		points, err := geoJSONPoints(positions)
+
+		if err != nil {
+			return err
+		}
+
+		for _, point := range points {
+			point.Name = name
+			point.Description = description
+			point.Type = kind
+			g.Waypoints = append(g.Waypoints, point)
+		}
+	case "LineString", "MultiLineString":
+		var lines [][][]float64
+
+		if object.Type == "LineString" {
+			lines = make([][][]float64, 1)
+			if err := json.Unmarshal(object.Coordinates, &lines[0]); err != nil {
+				return err
+			}
+		} else if err := json.Unmarshal(object.Coordinates, &lines); err != nil {
+			return err
+		}
+
+		track := Track{Name: name, Description: description, Type: kind}
+
+		for _, line := range lines {
+			points, err := geoJSONPoints(line)
+
+			if err != nil {
+				return err
+			}
+
+			track.TrackSegments = append(track.TrackSegments, TrackSegment{TrackPoint: points})
+		}
+
+		g.Tracks = append(g.Tracks, track)
+	}
+
+	return nil
+}
+
+// geoJSONPoints returns the GeoJSON positions as points.
+func geoJSONPoints(positions [][]float64) ([]WayPoint, error) {
+	points := make([]WayPoint, len(positions))
+
+	for i, position := range positions {
+		if len(position) < 2 {
+			return nil, fmt.Errorf("gpx: invalid GeoJSON position %v", position)
+		}
+
+		points[i] = WayPoint{Latitude: position[1], Longitude: position[0]}
+
+		if len(position) > 2 {
+			points[i].Elevation = position[2]
+			points[i].ElevationSet = true
+		}
+	}
+
+	return points, nil
+}
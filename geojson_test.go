@@ -0,0 +1,163 @@
+package gpx
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testGeoJSON struct {
+	Type     string `json:"type"`
+	Features []struct {
+		Type     string `json:"type"`
+		Geometry struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+		Properties map[string]interface{} `json:"properties"`
+	} `json:"features"`
+}
+
+func TestToGeoJSON(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	output, err := gpx.ToGeoJSON()
+
+	assert.NoError(t, err)
+
+	var collection testGeoJSON
+	assert.NoError(t, json.Unmarshal(output, &collection))
+
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	assert.Len(t, collection.Features, 1)
+
+	feature := collection.Features[0]
+
+	assert.Equal(t, "Feature", feature.Type)
+	assert.Equal(t, "LineString", feature.Geometry.Type)
+	assert.Equal(t, "Strava Running Sample", feature.Properties["name"])
+
+	var coordinates [][]float64
+	assert.NoError(t, json.Unmarshal(feature.Geometry.Coordinates, &coordinates))
+
+	assert.Len(t, coordinates, 15)
+	assert.Equal(t, []float64{121.516609, 25.039374, 15.4}, coordinates[0])
+}
+
+func TestToGeoJSONMultipleSegments(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	output, err := gpx.ToGeoJSON()
+
+	assert.NoError(t, err)
+
+	var collection testGeoJSON
+	assert.NoError(t, json.Unmarshal(output, &collection))
+
+	assert.Equal(t, "MultiLineString", collection.Features[0].Geometry.Type)
+
+	var lines [][][]float64
+	assert.NoError(t, json.Unmarshal(collection.Features[0].Geometry.Coordinates, &lines))
+
+	assert.Len(t, lines, 2)
+	assert.Len(t, lines[1], 3)
+}
+
+func TestToGeoJSONWaypoints(t *testing.T) {
+	b := openGPX("_data/waypoints.gpx")
+	gpx, _ := ReadGPX(b)
+
+	output, err := gpx.ToGeoJSON()
+
+	assert.NoError(t, err)
+
+	var collection testGeoJSON
+	assert.NoError(t, json.Unmarshal(output, &collection))
+
+	assert.Len(t, collection.Features, 3)
+	assert.Equal(t, "Point", collection.Features[0].Geometry.Type)
+	assert.Equal(t, "Taipei 101", collection.Features[0].Properties["name"])
+	assert.JSONEq(t, "[121.564472,25.033964,10]", string(collection.Features[0].Geometry.Coordinates))
+}
+
+func TestToGeoJSONWithoutElevation(t *testing.T) {
+	gpx := &GPX{Waypoints: []WayPoint{{Latitude: 25, Longitude: 121}}}
+
+	output, err := gpx.ToGeoJSON()
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[121,25]},"properties":{}}]}`, string(output))
+}
+
+func TestToGeoJSONEmpty(t *testing.T) {
+	output, err := (&GPX{}).ToGeoJSON()
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"FeatureCollection","features":[]}`, string(output))
+}
+
+func TestFromGeoJSON(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+	gpx.Waypoints = []WayPoint{{Latitude: 25.033964, Longitude: 121.564472, Name: "Taipei 101"}}
+
+	output, err := gpx.ToGeoJSON()
+	assert.NoError(t, err)
+
+	result, err := FromGeoJSON(output)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1", result.Version)
+	assert.Len(t, result.Tracks, 1)
+	assert.Equal(t, gpx.Tracks[0].Name, result.Tracks[0].Name)
+	assert.Len(t, result.Tracks[0].TrackSegments, 2)
+
+	for i, segment := range gpx.Tracks[0].TrackSegments {
+		points := result.Tracks[0].TrackSegments[i].TrackPoint
+
+		assert.Len(t, points, len(segment.TrackPoint))
+
+		for j, point := range segment.TrackPoint {
+			assert.Equal(t, point.Latitude, points[j].Latitude)
+			assert.Equal(t, point.Longitude, points[j].Longitude)
+			assert.Equal(t, point.Elevation, points[j].Elevation)
+		}
+	}
+
+	assert.Equal(t, []WayPoint{{Latitude: 25.033964, Longitude: 121.564472, Name: "Taipei 101"}}, result.Waypoints)
+}
+
+func TestFromGeoJSONGeometry(t *testing.T) {
+	result, err := FromGeoJSON([]byte(`{"type":"LineString","coordinates":[[121.5,25,10],[121.6,25.1]]}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []WayPoint{
+		{Latitude: 25, Longitude: 121.5, Elevation: 10, ElevationSet: true},
+		{Latitude: 25.1, Longitude: 121.6},
+	}, result.Tracks[0].TrackSegments[0].TrackPoint)
+
+	result, err = FromGeoJSON([]byte(`{"type":"Feature","geometry":{"type":"Point","coordinates":[121.5,25]},"properties":{"name":"Start"}}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []WayPoint{{Latitude: 25, Longitude: 121.5, Name: "Start"}}, result.Waypoints)
+
+	result, err = FromGeoJSON([]byte(`{"type":"Polygon","coordinates":[[[121.5,25],[121.6,25],[121.5,25.1],[121.5,25]]]}`))
+
+	assert.NoError(t, err)
+	assert.Empty(t, result.Tracks)
+	assert.Empty(t, result.Waypoints)
+}
+
+func TestFromGeoJSONInvalid(t *testing.T) {
+	_, err := FromGeoJSON([]byte(`{"type":"Point","coordinates":[121.5]}`))
+	assert.Error(t, err)
+
+	_, err = FromGeoJSON([]byte(`{"type":"LineString","coordinates":"121.5"}`))
+	assert.Error(t, err)
+
+	_, err = FromGeoJSON([]byte(`not json`))
+	assert.Error(t, err)
+}
@@ -3,9 +3,15 @@
 package gpx
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/xml"
 	"io"
 	"math"
+	"os"
+	"strings"
 	"time"
 
 	"golang.org/x/net/html/charset"
@@ -15,43 +21,113 @@ import (
 // ref: https://en.wikipedia.org/wiki/Earth_radius
 const EARTHRADIUS = 6371
 
+// Imperial unit conversion factors.
+const (
+	// KilometersPerMile is the length of an international mile in kilometers.
+	KilometersPerMile = 1.609344
+
+	// MetersPerFoot is the length of an international foot in meters.
+	MetersPerFoot = 0.3048
+)
+
 // GPX is the representation gpxType.
+// It is also encoded by encoding/json with lower camel case keys, the XML
+// element names are left out.
 type GPX struct {
-	XMLName  string    `xml:"gpx"`
-	Creator  string    `xml:"creator,attr,omitempty"`
-	Version  string    `xml:"version,attr,omitempty"`
-	Metadata *MetaData `xml:"metadata,omitempty"`
-	Tracks   []Track   `xml:"trk,omitempty"`
+	XMLName   xml.Name   `xml:"gpx" json:"-"`
+	Creator   string     `xml:"creator,attr,omitempty" json:"creator,omitempty"`
+	Version   string     `xml:"version,attr,omitempty" json:"version,omitempty"`
+	Metadata  *MetaData  `xml:"metadata,omitempty" json:"metadata,omitempty"`
+	Waypoints []WayPoint `xml:"wpt,omitempty" json:"waypoints,omitempty"`
+	Routes    []Route    `xml:"rte,omitempty" json:"routes,omitempty"`
+	Tracks    []Track    `xml:"trk,omitempty" json:"tracks,omitempty"`
+
+	// Namespaces holds the namespaces declared on the gpx element by prefix
+	// when read, the default namespace has an empty prefix. They are declared
+	// again when written.
+	Namespaces map[string]string `xml:"-" json:"namespaces,omitempty"`
 }
 
 // MetaData is the information about the GPX file, author,
 // and copyright restrictions goes in the metadata section.
 type MetaData struct {
-	XMLName   xml.Name `xml:"metadata"`
-	Timestamp string   `xml:"time,omitempty"`
+	XMLName     xml.Name   `xml:"metadata" json:"-"`
+	Name        string     `xml:"name,omitempty" json:"name,omitempty"`
+	Description string     `xml:"desc,omitempty" json:"description,omitempty"`
+	Author      *Person    `xml:"author,omitempty" json:"author,omitempty"`
+	Copyright   *Copyright `xml:"copyright,omitempty" json:"copyright,omitempty"`
+	Links       []Link     `xml:"link,omitempty" json:"links,omitempty"`
+	Timestamp   string     `xml:"time,omitempty" json:"time,omitempty"`
+	Keywords    string     `xml:"keywords,omitempty" json:"keywords,omitempty"`
+	Bounds      *Bounds    `xml:"bounds,omitempty" json:"bounds,omitempty"`
+}
+
+// Person is a person or organization.
+type Person struct {
+	Name  string `xml:"name,omitempty" json:"name,omitempty"`
+	Email *Email `xml:"email,omitempty" json:"email,omitempty"`
+	Link  *Link  `xml:"link,omitempty" json:"link,omitempty"`
+}
+
+// Copyright is the copyright holder of the GPX and the license under which
+// it is released.
+type Copyright struct {
+	Author  string `xml:"author,attr" json:"author"`
+	Year    string `xml:"year,omitempty" json:"year,omitempty"`
+	License string `xml:"license,omitempty" json:"license,omitempty"` // URL of the license text
+}
+
+// Email is an email address, broken into two parts (id and domain)
+// in order to help prevent email harvesting.
+type Email struct {
+	ID     string `xml:"id,attr" json:"id"`
+	Domain string `xml:"domain,attr" json:"domain"`
+}
+
+// Bounds is two lat/lon pairs defining the extent of an element.
+type Bounds struct {
+	MinLatitude  float64 `xml:"minlat,attr" json:"minLatitude"`
+	MinLongitude float64 `xml:"minlon,attr" json:"minLongitude"`
+	MaxLatitude  float64 `xml:"maxlat,attr" json:"maxLatitude"`
+	MaxLongitude float64 `xml:"maxlon,attr" json:"maxLongitude"`
 }
 
 // Link is an external resource (Web page, digital photo, video clip, etc)
 // with additional information.
 type Link struct {
-	XMLName xml.Name `xml:"link"`
-	URL     string   `xml:"href,attr,omitempty"`
-	Text    string   `xml:"text,omitempty"`
-	Type    string   `xml:"type,omitempty"`
+	XMLName xml.Name `xml:"link" json:"-"`
+	URL     string   `xml:"href,attr,omitempty" json:"url,omitempty"`
+	Text    string   `xml:"text,omitempty" json:"text,omitempty"`
+	Type    string   `xml:"type,omitempty" json:"type,omitempty"`
+}
+
+// Route is the representation rte - an ordered list of waypoints representing
+// a series of turn points leading to a destination.
+type Route struct {
+	XMLName     xml.Name    `xml:"rte" json:"-"`
+	Name        string      `xml:"name,omitempty" json:"name,omitempty"`
+	Comment     string      `xml:"cmt,omitempty" json:"comment,omitempty"`
+	Description string      `xml:"desc,omitempty" json:"description,omitempty"`
+	Source      string      `xml:"src,omitempty" json:"source,omitempty"`
+	Links       []Link      `xml:"link,omitempty" json:"links,omitempty"`
+	Number      int         `xml:"number,omitempty" json:"number,omitempty"`
+	Type        string      `xml:"type,omitempty" json:"type,omitempty"`
+	Extensions  *Extensions `xml:"extensions,omitempty" json:"extensions,omitempty"`
+	RoutePoints []WayPoint  `xml:"rtept,omitempty" json:"points,omitempty"`
 }
 
 // Track is the representation trk - an ordered list of points describing a path.
 type Track struct {
-	XMLName       xml.Name       `xml:"trk"`
-	Name          string         `xml:"name,omitempty"`
-	Comment       string         `xml:"cmt,omitempty"`
-	Description   string         `xml:"desc,omitempty"`
-	Source        string         `xml:"src,omitempty"`
-	Links         []Link         `xml:"link,omitempty"`
-	Number        int            `xml:"number,omitempty"`
-	Type          string         `xml:"type,omitempty"`
-	Extensions    *Extensions    `xml:"extensions,omitempty"`
-	TrackSegments []TrackSegment `xml:"trkseg,omitempty"`
+	XMLName       xml.Name       `xml:"trk" json:"-"`
+	Name          string         `xml:"name,omitempty" json:"name,omitempty"`
+	Comment       string         `xml:"cmt,omitempty" json:"comment,omitempty"`
+	Description   string         `xml:"desc,omitempty" json:"description,omitempty"`
+	Source        string         `xml:"src,omitempty" json:"source,omitempty"`
+	Links         []Link         `xml:"link,omitempty" json:"links,omitempty"`
+	Number        int            `xml:"number,omitempty" json:"number,omitempty"`
+	Type          string         `xml:"type,omitempty" json:"type,omitempty"`
+	Extensions    *Extensions    `xml:"extensions,omitempty" json:"extensions,omitempty"`
+	TrackSegments []TrackSegment `xml:"trkseg,omitempty" json:"segments,omitempty"`
 }
 
 // Extensions is the representation extension.
@@ -61,51 +137,88 @@ type Extensions struct {
 
 // TrackSegment holds a list of TrackPoint which are logically connected in order.
 type TrackSegment struct {
-	XMLName    xml.Name    `xml:"trkseg"`
-	TrackPoint []WayPoint  `xml:"trkpt"`
-	Extensions *Extensions `xml:"extensions,omitempty"`
+	XMLName    xml.Name    `xml:"trkseg" json:"-"`
+	TrackPoint []WayPoint  `xml:"trkpt" json:"points,omitempty"`
+	Extensions *Extensions `xml:"extensions,omitempty" json:"extensions,omitempty"`
 }
 
 // WayPoint is a point of interest, or named feature on a map.
+// It is used for wpt, trkpt and rtept elements, so the element name
+// comes from the enclosing field. Course (degrees) and Speed (m/s) hold the
+// recorded values rather than ones computed from the positions, they are read
+// from the GPX 1.0 course and speed elements, which some devices keep writing
+// in GPX 1.1, or else from the Garmin TrackPointExtension. As course and speed
+// aren't GPX 1.1 elements, they are written in the TrackPointExtension.
+// AgeOfGpsData is the ageofdgpsdata element, the number of seconds since the
+// last DGPS update, it used to be mapped to ageofgpsdata, which isn't a GPX
+// element, and keeps its name for compatibility.
 type WayPoint struct {
-	XMLName                       xml.Name              `xml:"trkpt"`
-	Latitude                      float64               `xml:"lat,attr"`
-	Longitude                     float64               `xml:"lon,attr"`
-	Elevation                     float64               `xml:"ele,omitempty"`
-	Timestamp                     string                `xml:"time,omitempty"`
-	MagneticVariation             Degrees               `xml:"magvar,omitempty"`
-	GeoIDHeight                   float64               `xml:"geoidheight,omitempty"`
-	Name                          string                `xml:"name,omitempty"`
-	Comment                       string                `xml:"cmt,omitempty"`
-	Description                   string                `xml:"desc,omitempty"`
-	Source                        string                `xml:"src,omitempty"`
-	Links                         []Link                `xml:"link,omitempty"`
-	Symbol                        string                `xml:"sym,omitempty"`
-	Type                          string                `xml:"type,omitempty"`
-	Fix                           Fix                   `xml:"fix,omitempty"`
-	Sat                           int                   `xml:"sat,omitempty"`
-	HorizontalDilutionOfPrecision float64               `xml:"hdop,omitempty"`
-	VerticalDilutionOfPrecision   float64               `xml:"vdop,omitempty"`
-	PositionDilutionOfPrecision   float64               `xml:"pdop,omitempty"`
-	AgeOfGpsData                  float64               `xml:"ageofgpsdata,omitempty"`
-	DifferentialGPSID             DGPSStation           `xml:"dgpsid,omitempty"`
-	Extensions                    *TrackPointExtensions `xml:"extensions,omitempty"`
+	XMLName                       xml.Name              `xml:"-" json:"-"`
+	Latitude                      float64               `xml:"lat,attr" json:"latitude"`
+	Longitude                     float64               `xml:"lon,attr" json:"longitude"`
+	Elevation                     float64               `xml:"ele,omitempty" json:"elevation,omitempty"`
+	Timestamp                     string                `xml:"time,omitempty" json:"time,omitempty"`
+	Course                        Degrees               `xml:"course,omitempty" json:"course,omitempty"`
+	Speed                         float64               `xml:"speed,omitempty" json:"speed,omitempty"`
+	MagneticVariation             Degrees               `xml:"magvar,omitempty" json:"magneticVariation,omitempty"`
+	GeoIDHeight                   float64               `xml:"geoidheight,omitempty" json:"geoidHeight,omitempty"`
+	Name                          string                `xml:"name,omitempty" json:"name,omitempty"`
+	Comment                       string                `xml:"cmt,omitempty" json:"comment,omitempty"`
+	Description                   string                `xml:"desc,omitempty" json:"description,omitempty"`
+	Source                        string                `xml:"src,omitempty" json:"source,omitempty"`
+	Links                         []Link                `xml:"link,omitempty" json:"links,omitempty"`
+	Symbol                        string                `xml:"sym,omitempty" json:"symbol,omitempty"`
+	Type                          string                `xml:"type,omitempty" json:"type,omitempty"`
+	Fix                           Fix                   `xml:"fix,omitempty" json:"fix,omitempty"`
+	Sat                           int                   `xml:"sat,omitempty" json:"sat,omitempty"`
+	HorizontalDilutionOfPrecision float64               `xml:"hdop,omitempty" json:"hdop,omitempty"`
+	VerticalDilutionOfPrecision   float64               `xml:"vdop,omitempty" json:"vdop,omitempty"`
+	PositionDilutionOfPrecision   float64               `xml:"pdop,omitempty" json:"pdop,omitempty"`
+	AgeOfGpsData                  float64               `xml:"ageofdgpsdata,omitempty" json:"ageOfDgpsData,omitempty"`
+	DifferentialGPSID             DGPSStation           `xml:"dgpsid,omitempty" json:"dgpsId,omitempty"`
+	Extensions                    *TrackPointExtensions `xml:"extensions,omitempty" json:"extensions,omitempty"`
+
+	// ElevationSet reports that the point has an elevation even when it is
+	// 0, it is set when an ele element is read. See HasElevation.
+	ElevationSet bool `xml:"-" json:"elevationSet,omitempty"`
 }
 
 // TrackPointExtensions extend GPX by adding your own elements from another schema
 type TrackPointExtensions struct {
-	XMLName              xml.Name             `xml:"extensions"`
-	TrackPointExtensions *TrackPointExtension `xml:"TrackPointExtension,omitempty"`
+	XMLName              xml.Name             `xml:"extensions" json:"-"`
+	TrackPointExtensions *TrackPointExtension `xml:"TrackPointExtension,omitempty" json:"trackPointExtension,omitempty"`
 }
 
-// TrackPointExtension tracks temperature, heart rate and cadence specific to devices
+// TrackPointExtension tracks temperature, heart rate and cadence specific to devices.
+// Elements are matched by local name, so both the Garmin TrackPointExtension
+// v1 and v2 namespaces are read whatever prefix (gpxtpx, ns3, ...) they use.
 type TrackPointExtension struct {
-	XMLName      xml.Name `xml:"TrackPointExtension"`
-	Temperature  float64  `xml:"atemp,omitempty"`
-	WTemperature float64  `xml:"wtemp,omitempty"`
-	Depth        float64  `xml:"depth,omitempty"`
-	HeartRate    int      `xml:"hr,omitempty"`
-	Cadence      int      `xml:"cad,omitempty"`
+	XMLName      xml.Name `xml:"TrackPointExtension" json:"-"`
+	Temperature  float64  `xml:"atemp,omitempty" json:"temperature,omitempty"`
+	WTemperature float64  `xml:"wtemp,omitempty" json:"waterTemperature,omitempty"`
+	Depth        float64  `xml:"depth,omitempty" json:"depth,omitempty"`
+	HeartRate    int      `xml:"hr,omitempty" json:"heartRate,omitempty"`
+	Cadence      int      `xml:"cad,omitempty" json:"cadence,omitempty"`
+}
+
+// pointExtensions is the extensions element of a point as it is read and
+// written, its TrackPointExtension also holds the speed and course elements
+// of the TrackPointExtension v2 schema, which are the Speed and Course of the
+// point.
+type pointExtensions struct {
+	XMLName             xml.Name
+	TrackPointExtension *pointTrackPointExtension `xml:"TrackPointExtension,omitempty"`
+}
+
+type pointTrackPointExtension struct {
+	XMLName      xml.Name
+	Temperature  float64 `xml:"atemp,omitempty"`
+	WTemperature float64 `xml:"wtemp,omitempty"`
+	Depth        float64 `xml:"depth,omitempty"`
+	HeartRate    int     `xml:"hr,omitempty"`
+	Cadence      int     `xml:"cad,omitempty"`
+	Speed        float64 `xml:"speed,omitempty"`
+	Course       Degrees `xml:"course,omitempty"`
 }
 
 // Degrees is used for bearing, heading, course. Units are decimal degrees, true (not magnetic). (0.0 <= value < 360.0)
@@ -121,31 +234,255 @@ type DGPSStation int
 
 // Pace is the representation a running pace.
 type Pace struct {
-	Minutes int
-	Seconds int
+	Minutes int `json:"minutes"`
+	Seconds int `json:"seconds"`
 }
 
 // Point is the representation a point of latitude and longitude
 type Point struct {
-	Latitude  float64
-	Longitude float64
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// gpx10 holds the GPX 1.0 elements of the gpx element,
+// they are moved into the metadata element in GPX 1.1.
+type gpx10 struct {
+	*GPX
+	Name        string  `xml:"name"`
+	Description string  `xml:"desc"`
+	Author      string  `xml:"author"`
+	Email       string  `xml:"email"`
+	URL         string  `xml:"url"`
+	URLName     string  `xml:"urlname"`
+	Timestamp   string  `xml:"time"`
+	Keywords    string  `xml:"keywords"`
+	Bounds      *Bounds `xml:"bounds"`
+
+	Attrs []xml.Attr `xml:",any,attr"`
 }
 
 // ReadGPX is a GPX reader and return a GPX object and error.
+// GPX 1.0 documents are supported, the name, desc, author, email, url,
+// urlname, time, keywords and bounds elements of the gpx element are
+// mapped into Metadata.
 func ReadGPX(r io.Reader) (*GPX, error) {
+	return ReadGPXContext(context.Background(), r)
+}
+
+// ReadGPXBytes reads the GPX document held in b like ReadGPX.
+func ReadGPXBytes(b []byte) (*GPX, error) {
+	return ReadGPX(bytes.NewReader(b))
+}
+
+// ReadGPXContext is a GPX reader like ReadGPX which stops reading and
+// returns ctx.Err() when ctx is done.
+func ReadGPXContext(ctx context.Context, r io.Reader) (*GPX, error) {
 	gpx := &GPX{}
+	root := &gpx10{GPX: gpx}
 
 	// ref: https://stackoverflow.com/questions/6002619/unmarshal-an-iso-8859-1-xml-input-in-go
-	d := xml.NewDecoder(r)
+	d := xml.NewDecoder(&contextReader{ctx: ctx, r: r})
 	d.CharsetReader = charset.NewReaderLabel
-	err := d.Decode(gpx)
+	err := d.Decode(root)
+
+	if gpx.Version == "1.0" && gpx.Metadata == nil {
+		gpx.Metadata = root.metadata()
+	}
+
+	gpx.Namespaces = root.namespaces()
 
 	return gpx, err
 }
 
-// Time returns TrackPoint timestamp as Time
+// metadata returns the GPX 1.0 elements as GPX 1.1 metadata.
+func (root *gpx10) metadata() *MetaData {
+	metadata := &MetaData{
+		Name:        root.Name,
+		Description: root.Description,
+		Timestamp:   root.Timestamp,
+		Keywords:    root.Keywords,
+		Bounds:      root.Bounds,
+	}
+
+	if root.Author != "" || root.Email != "" {
+		metadata.Author = &Person{Name: root.Author}
+
+		if i := strings.LastIndex(root.Email, "@"); i != -1 {
+			metadata.Author.Email = &Email{ID: root.Email[:i], Domain: root.Email[i+1:]}
+		}
+	}
+
+	if root.URL != "" {
+		metadata.Links = []Link{{URL: root.URL, Text: root.URLName}}
+	}
+
+	return metadata
+}
+
+// namespaces returns the namespaces declared by the attributes of the gpx
+// element, nil when there is none.
+func (root *gpx10) namespaces() map[string]string {
+	var namespaces map[string]string
+
+	for _, attr := range root.Attrs {
+		var prefix string
+
+		switch {
+		case attr.Name.Space == "xmlns":
+			prefix = attr.Name.Local
+		case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+			prefix = ""
+		default:
+			continue
+		}
+
+		if namespaces == nil {
+			namespaces = map[string]string{}
+		}
+
+		namespaces[prefix] = attr.Value
+	}
+
+	return namespaces
+}
+
+// contextReader is a reader which fails with the context error once the
+// context is done, it is checked before every read of the decoder.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return cr.r.Read(p)
+}
+
+// ReadGPXFile reads the GPX file at path and return a GPX object and error.
+// A gzip compressed file is decompressed transparently, it is detected by
+// the .gz extension or by the gzip magic bytes.
+func ReadGPXFile(path string) (*GPX, error) {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(2)
+
+	if strings.HasSuffix(path, ".gz") || (len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b) {
+		gr, err := gzip.NewReader(br)
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer gr.Close()
+
+		return ReadGPX(gr)
+	}
+
+	return ReadGPX(br)
+}
+
+// Time returns MetaData timestamp as Time, the zero Time is returned
+// when the timestamp can't be parsed.
+func (m *MetaData) Time() time.Time {
+	t, err := parseTime(m.Timestamp)
+
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
+
+// HasElevation reports whether the point has an elevation, that is when
+// ElevationSet is true or the elevation isn't 0. A point built without
+// ElevationSet at sea level can't be told apart from one without elevation.
+func (w *WayPoint) HasElevation() bool {
+	return w.ElevationSet || w.Elevation != 0
+}
+
+// ElevationFeet returns the elevation of the point in feet.
+func (w *WayPoint) ElevationFeet() float64 {
+	return w.Elevation / MetersPerFoot
+}
+
+// UnmarshalXML reads the point and sets ElevationSet when it has an ele
+// element, so an elevation of 0 isn't mistaken for a missing one.
+func (w *WayPoint) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type wayPoint WayPoint
+
+	point := struct {
+		Elevation  *float64         `xml:"ele"`
+		Extensions *pointExtensions `xml:"extensions"`
+		*wayPoint
+	}{wayPoint: (*wayPoint)(w)}
+
+	if err := d.DecodeElement(&point, &start); err != nil {
+		return err
+	}
+
+	if point.Elevation != nil {
+		w.Elevation = *point.Elevation
+		w.ElevationSet = true
+	}
+
+	if point.Extensions != nil {
+		w.setExtensions(point.Extensions)
+	}
+
+	return nil
+}
+
+// setExtensions sets the Extensions of the point from the extensions element
+// read. The speed and course of its TrackPointExtension are moved to Speed and
+// Course unless the point has them as GPX 1.0 elements, and a
+// TrackPointExtension holding nothing else is left out.
+func (w *WayPoint) setExtensions(extensions *pointExtensions) {
+	w.Extensions = &TrackPointExtensions{XMLName: extensions.XMLName}
+	extension := extensions.TrackPointExtension
+
+	if extension == nil {
+		return
+	}
+
+	if w.Speed == 0 {
+		w.Speed = extension.Speed
+	}
+
+	if w.Course == 0 {
+		w.Course = extension.Course
+	}
+
+	trackPointExtension := TrackPointExtension{
+		XMLName:      extension.XMLName,
+		Temperature:  extension.Temperature,
+		WTemperature: extension.WTemperature,
+		Depth:        extension.Depth,
+		HeartRate:    extension.HeartRate,
+		Cadence:      extension.Cadence,
+	}
+
+	if (extension.Speed != 0 || extension.Course != 0) && trackPointExtension == (TrackPointExtension{XMLName: extension.XMLName}) {
+		w.Extensions = nil
+		return
+	}
+
+	w.Extensions.TrackPointExtensions = &trackPointExtension
+}
+
+// Time returns TrackPoint timestamp as Time, the zero Time is returned
+// when the timestamp can't be parsed.
 func (w *WayPoint) Time() time.Time {
-	t, err := time.Parse(time.RFC3339, w.Timestamp)
+	t, err := w.TimeParse()
 
 	if err != nil {
 		return time.Time{}
@@ -154,6 +491,11 @@ func (w *WayPoint) Time() time.Time {
 	return t
 }
 
+// TimeParse returns TrackPoint timestamp as Time and the parsing error.
+func (w *WayPoint) TimeParse() (time.Time, error) {
+	return parseTime(w.Timestamp)
+}
+
 // Distance returns two point distance.
 // ref: https://www.movable-type.co.uk/scripts/latlong.html
 func (w *WayPoint) Distance(w2 *WayPoint) float64 {
@@ -170,9 +512,107 @@ func (w *WayPoint) Distance(w2 *WayPoint) float64 {
 	return EARTHRADIUS * c
 }
 
-// Duration returns the duration of all tracks in a GPX in seconds.
+// Points returns every track point of every track segment in order.
+// Segment boundaries are not preserved, the points of all segments are
+// collapsed into a single slice.
+func (g *GPX) Points() []WayPoint {
+	var points []WayPoint
+
+	for _, track := range g.Tracks {
+		for _, segment := range track.TrackSegments {
+			points = append(points, segment.TrackPoint...)
+		}
+	}
+
+	return points
+}
+
+// NumPoints returns the number of track points of every track segment.
+func (g *GPX) NumPoints() int {
+	var n int
+
+	for i := range g.Tracks {
+		for j := range g.Tracks[i].TrackSegments {
+			n += len(g.Tracks[i].TrackSegments[j].TrackPoint)
+		}
+	}
+
+	return n
+}
+
+// NumSegments returns the number of track segments of every track, the
+// empty ones included.
+func (g *GPX) NumSegments() int {
+	var n int
+
+	for i := range g.Tracks {
+		n += len(g.Tracks[i].TrackSegments)
+	}
+
+	return n
+}
+
+// NumRoutePoints returns the number of route points of every route. The
+// number of waypoints is len(g.Waypoints).
+func (g *GPX) NumRoutePoints() int {
+	var n int
+
+	for i := range g.Routes {
+		n += len(g.Routes[i].RoutePoints)
+	}
+
+	return n
+}
+
+// AppendPoint appends the point to the last segment of the last track. A
+// track and a segment are created first when the GPX has no track, or when
+// the last track has no segment.
+func (g *GPX) AppendPoint(w WayPoint) {
+	if len(g.Tracks) == 0 {
+		g.Tracks = append(g.Tracks, Track{})
+	}
+
+	track := &g.Tracks[len(g.Tracks)-1]
+
+	if len(track.TrackSegments) == 0 {
+		track.TrackSegments = append(track.TrackSegments, TrackSegment{})
+	}
+
+	segment := &track.TrackSegments[len(track.TrackSegments)-1]
+	segment.TrackPoint = append(segment.TrackPoint, w)
+}
+
+// WalkSegments calls fn for every track segment in order with the index of
+// its track and its index in the track. The segment is passed by pointer so
+// fn can modify it in place.
+func (g *GPX) WalkSegments(fn func(trackIdx, segIdx int, seg *TrackSegment)) {
+	for i := range g.Tracks {
+		for j := range g.Tracks[i].TrackSegments {
+			fn(i, j, &g.Tracks[i].TrackSegments[j])
+		}
+	}
+}
+
+// WalkPoints calls fn for every track point in order with the index of its
+// track, of its segment in the track and of the point in the segment. The
+// point is passed by pointer so fn can modify it in place.
+func (g *GPX) WalkPoints(fn func(trackIdx, segIdx, pointIdx int, p *WayPoint)) {
+	g.WalkSegments(func(trackIdx, segIdx int, seg *TrackSegment) {
+		for k := range seg.TrackPoint {
+			fn(trackIdx, segIdx, k, &seg.TrackPoint[k])
+		}
+	})
+}
+
+// Duration returns the duration of all tracks in a GPX in seconds, from the
+// first track point to the last one. It returns 0 when there are fewer than
+// two track points.
 func (g *GPX) Duration() float64 {
-	trackPoints := g.Tracks[0].TrackSegments[0].TrackPoint
+	trackPoints := g.Points()
+
+	if len(trackPoints) < 2 {
+		return 0.0
+	}
 
 	start := trackPoints[0].Time()
 	end := trackPoints[len(trackPoints)-1].Time()
@@ -186,39 +626,136 @@ func (g *GPX) Duration() float64 {
 	return duration.Seconds()
 }
 
-// Distance returns total distance
+// Distance returns total distance of every track segment.
+// Segments are not connected to each other, the gap between the last point
+// of a segment and the first point of the next one is not counted, see
+// DistanceConnected to count it.
 func (g *GPX) Distance() float64 {
 	var totalDistance float64
-	trackPoints := g.Tracks[0].TrackSegments[0].TrackPoint
 
-	for i := 1; i < len(trackPoints); i++ {
-		totalDistance += trackPoints[i-1].Distance(&trackPoints[i])
+	for i := range g.Tracks {
+		totalDistance += g.Tracks[i].Length()
 	}
 
 	return totalDistance
 }
 
+// DistanceMiles returns the total distance of every track segment in miles,
+// see Distance.
+func (g *GPX) DistanceMiles() float64 {
+	return g.Distance() / KilometersPerMile
+}
+
+// DistanceConnected returns the total distance like Distance, but with the
+// segments of a track connected, the gap between the last point of a segment
+// and the first point of the next one is counted. Tracks are not connected to
+// each other.
+func (g *GPX) DistanceConnected() float64 {
+	var totalDistance float64
+
+	for i := range g.Tracks {
+		totalDistance += g.Tracks[i].FlattenSegments().Length()
+	}
+
+	return totalDistance
+}
+
+// Length returns the total distance in kilometers of every segment of the
+// track, without connecting the segments.
+func (t *Track) Length() float64 {
+	var length float64
+
+	for i := range t.TrackSegments {
+		length += t.TrackSegments[i].Length()
+	}
+
+	return length
+}
+
+// Length returns the distance in kilometers between every consecutive track
+// point of the segment.
+func (ts *TrackSegment) Length() float64 {
+	var length float64
+
+	for i := 1; i < len(ts.TrackPoint); i++ {
+		length += ts.TrackPoint[i-1].Distance(&ts.TrackPoint[i])
+	}
+
+	return length
+}
+
+// Duration returns the time in seconds from the first timestamped point of
+// the track to the last one, the pauses between its segments included.
+// It returns 0 when there are fewer than two timestamped points.
+func (t *Track) Duration() float64 {
+	var start, end time.Time
+
+	for i := range t.TrackSegments {
+		segmentStart, segmentEnd := t.TrackSegments[i].timeSpan()
+
+		if start.IsZero() {
+			start = segmentStart
+		}
+
+		if !segmentEnd.IsZero() {
+			end = segmentEnd
+		}
+	}
+
+	return durationBetween(start, end)
+}
+
+// Duration returns the time in seconds from the first timestamped point of
+// the segment to the last one. It returns 0 when there are fewer than two
+// timestamped points.
+func (ts *TrackSegment) Duration() float64 {
+	return durationBetween(ts.timeSpan())
+}
+
+// timeSpan returns the time of the first and the last timestamped points
+// of the segment, zero when there is none.
+func (ts *TrackSegment) timeSpan() (time.Time, time.Time) {
+	var start, end time.Time
+
+	for i := range ts.TrackPoint {
+		if t := ts.TrackPoint[i].Time(); !t.IsZero() {
+			if start.IsZero() {
+				start = t
+			}
+
+			end = t
+		}
+	}
+
+	return start, end
+}
+
+// durationBetween returns the time in seconds from start to end, 0 when a
+// time is zero or end isn't after start.
+func durationBetween(start, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() || !end.After(start) {
+		return 0
+	}
+
+	return end.Sub(start).Seconds()
+}
+
 // PaceInKM returns running pace in kilometers.
+// A zero Pace is returned when the distance or the duration is 0.
 func (g *GPX) PaceInKM() *Pace {
-	paceInKM := int(g.Duration() / g.Distance())
-	minutesPaceInKm := int(paceInKM / 60)
-	secondsPaceInKm := paceInKM % 60
-
-	return &Pace{minutesPaceInKm, secondsPaceInKm}
+	return pace(g.Duration(), g.Distance())
 }
 
-// PaceInMile returns running pace in miles.
+// PaceInMile returns running pace per mile.
+// A zero Pace is returned when the distance or the duration is 0.
 func (g *GPX) PaceInMile() *Pace {
-	paceInKM := int(g.Duration() / g.Distance() / 1.609344)
-	minutesPaceInKm := int(paceInKM / 60)
-	secondsPaceInKm := paceInKM % 60
-
-	return &Pace{minutesPaceInKm, secondsPaceInKm}
+	return pace(g.Duration(), g.DistanceMiles())
 }
 
-// Elevations returns all the track point elevation.
+// Elevations returns all the track point elevation of every track segment.
+// A track point without elevation (see HasElevation) has an elevation of 0.
 func (g *GPX) Elevations() []float64 {
-	trackPoints := g.Tracks[0].TrackSegments[0].TrackPoint
+	trackPoints := g.Points()
 	elevations := make([]float64, len(trackPoints))
 
 	for i := range trackPoints {
@@ -228,28 +765,41 @@ func (g *GPX) Elevations() []float64 {
 	return elevations
 }
 
-// MinAndMixElevation returns min and mix elevation.
-func (g *GPX) MinAndMixElevation() (float64, float64) {
-	e := g.Elevations()
-	minElevation := e[0]
-	maxElevation := e[0]
+// MinAndMaxElevation returns min and max elevation of every track segment,
+// skipping the track points without elevation (see HasElevation). The ok
+// value is false when no track point has an elevation.
+func (g *GPX) MinAndMaxElevation() (min, max float64, ok bool) {
+	for _, point := range g.Points() {
+		if !point.HasElevation() {
+			continue
+		}
 
-	for _, value := range e {
-		if value < minElevation {
-			minElevation = value
+		if !ok || point.Elevation < min {
+			min = point.Elevation
 		}
 
-		if value > maxElevation {
-			maxElevation = value
+		if !ok || point.Elevation > max {
+			max = point.Elevation
 		}
+
+		ok = true
 	}
 
-	return minElevation, maxElevation
+	return min, max, ok
+}
+
+// MinAndMixElevation returns min and max elevation.
+//
+// Deprecated: Use MinAndMaxElevation instead.
+func (g *GPX) MinAndMixElevation() (float64, float64) {
+	min, max, _ := g.MinAndMaxElevation()
+
+	return min, max
 }
 
 // GetCoordinates return all track points latitude and longitude.
 func (g *GPX) GetCoordinates() []Point {
-	trackPoints := g.Tracks[0].TrackSegments[0].TrackPoint
+	trackPoints := g.Points()
 	coordinates := make([]Point, len(trackPoints))
 
 	for i, track := range trackPoints {
@@ -259,6 +809,98 @@ func (g *GPX) GetCoordinates() []Point {
 	return coordinates
 }
 
+// Distance returns the total distance of the route points.
+func (r *Route) Distance() float64 {
+	var totalDistance float64
+
+	for i := 1; i < len(r.RoutePoints); i++ {
+		totalDistance += r.RoutePoints[i-1].Distance(&r.RoutePoints[i])
+	}
+
+	return totalDistance
+}
+
+// Elevations returns all the route point elevation.
+func (r *Route) Elevations() []float64 {
+	elevations := make([]float64, len(r.RoutePoints))
+
+	for i := range r.RoutePoints {
+		elevations[i] = r.RoutePoints[i].Elevation
+	}
+
+	return elevations
+}
+
+// GetCoordinates return all route points latitude and longitude.
+func (r *Route) GetCoordinates() []Point {
+	coordinates := make([]Point, len(r.RoutePoints))
+
+	for i, point := range r.RoutePoints {
+		coordinates[i] = Point{Longitude: point.Longitude, Latitude: point.Latitude}
+	}
+
+	return coordinates
+}
+
+// ToTrack returns the route as a track with a single segment holding a copy
+// of the route points. The name, comment, description, source, links,
+// number, type and extensions carry over.
+func (r *Route) ToTrack() Track {
+	track := Track{
+		Name:        r.Name,
+		Comment:     r.Comment,
+		Description: r.Description,
+		Source:      r.Source,
+		Links:       cloneLinks(r.Links),
+		Number:      r.Number,
+		Type:        r.Type,
+		Extensions:  r.Extensions.clone(),
+	}
+
+	if len(r.RoutePoints) > 0 {
+		track.TrackSegments = []TrackSegment{{TrackPoint: clonePoints(r.RoutePoints)}}
+	}
+
+	return track
+}
+
+// timeLayouts are the accepted timestamp layouts, the first one is the
+// layout required by the GPX schema.
+var timeLayouts = []string{
+	time.RFC3339,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+}
+
+// pace returns the pace of the distance covered in duration seconds, rounded
+// to the nearest second, or a zero Pace when one of them is 0.
+func pace(duration, distance float64) *Pace {
+	if duration <= 0 || distance <= 0 {
+		return &Pace{}
+	}
+
+	seconds := int(math.Round(duration / distance))
+
+	return &Pace{seconds / 60, seconds % 60}
+}
+
+// parseTime parses a GPX timestamp, with or without fractional seconds.
+// A timestamp without time zone is treated as UTC.
+func parseTime(value string) (time.Time, error) {
+	var err error
+
+	for _, layout := range timeLayouts {
+		var t time.Time
+
+		if t, err = time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
 // toRadians converts degrees to radians.
 func toRadians(degree float64) float64 {
 	return degree * math.Pi / 180.0
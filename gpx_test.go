@@ -2,6 +2,8 @@ package gpx
 
 import (
 	"bytes"
+	"context"
+	"io"
 	"io/ioutil"
 	"log"
 	"math"
@@ -54,6 +56,53 @@ func TestWayPointTime(t *testing.T) {
 	assert.Equal(t, expectedTime, firstTrakPointTime)
 }
 
+func TestWayPointTimeParse(t *testing.T) {
+	cases := []struct {
+		timestamp string
+		expected  time.Time
+	}{
+		{"2019-10-26T21:21:11Z", time.Date(2019, 10, 26, 21, 21, 11, 0, time.UTC)},
+		{"2019-10-26T21:21:11.500Z", time.Date(2019, 10, 26, 21, 21, 11, 500000000, time.UTC)},
+		{"2019-10-26T23:21:11+02:00", time.Date(2019, 10, 26, 21, 21, 11, 0, time.UTC)},
+		{"2019-10-26T23:21:11.123+02:00", time.Date(2019, 10, 26, 21, 21, 11, 123000000, time.UTC)},
+		{"2019-10-26T23:21:11+0200", time.Date(2019, 10, 26, 21, 21, 11, 0, time.UTC)},
+		{"2019-10-26T21:21:11", time.Date(2019, 10, 26, 21, 21, 11, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		w := WayPoint{Timestamp: c.timestamp}
+		parsed, err := w.TimeParse()
+
+		assert.NoError(t, err, c.timestamp)
+		assert.True(t, c.expected.Equal(parsed), c.timestamp)
+		assert.True(t, c.expected.Equal(w.Time()), c.timestamp)
+	}
+}
+
+func TestWayPointTimeParseInvalid(t *testing.T) {
+	w := WayPoint{Timestamp: "not a time"}
+	parsed, err := w.TimeParse()
+
+	assert.Error(t, err)
+	assert.True(t, parsed.IsZero())
+	assert.True(t, w.Time().IsZero())
+}
+
+func TestDurationFractionalSeconds(t *testing.T) {
+	gpx := &GPX{
+		Tracks: []Track{{
+			TrackSegments: []TrackSegment{{
+				TrackPoint: []WayPoint{
+					{Timestamp: "2019-10-26T21:21:11.250Z"},
+					{Timestamp: "2019-10-26T21:21:12.750Z"},
+				},
+			}},
+		}},
+	}
+
+	assert.Equal(t, 1.5, gpx.Duration())
+}
+
 func TestDuration(t *testing.T) {
 	b := openGPX(testGPX)
 	gpx, _ := ReadGPX(b)
@@ -85,6 +134,22 @@ func TestGPXDistance(t *testing.T) {
 	assert.Less(t, float64(0.1), gpx.Distance())
 }
 
+func TestGPXDistanceMiles(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.InDelta(t, gpx.Distance(), gpx.DistanceMiles()*1.609344, 1e-12)
+	assert.InDelta(t, 0.2764, gpx.DistanceMiles(), 0.001)
+	assert.Equal(t, 0.0, (&GPX{}).DistanceMiles())
+}
+
+func TestElevationFeet(t *testing.T) {
+	w := WayPoint{Elevation: 30.48}
+
+	assert.InDelta(t, 100.0, w.ElevationFeet(), 1e-9)
+	assert.Equal(t, 0.0, (&WayPoint{}).ElevationFeet())
+}
+
 func TestPaceInKM(t *testing.T) {
 	b := openGPX(testGPX)
 	gpx, _ := ReadGPX(b)
@@ -100,7 +165,40 @@ func TestPaceInMile(t *testing.T) {
 
 	p := gpx.PaceInMile()
 
-	assert.Equal(t, &Pace{2, 59}, p)
+	assert.Equal(t, &Pace{7, 45}, p)
+}
+
+func TestPaceKnownValue(t *testing.T) {
+	start := WayPoint{Latitude: 25, Longitude: 121.5, Timestamp: "2020-05-03T07:00:00Z"}
+	end := start.Destination(0, KilometersPerMile)
+	gpx := newTestGPX([]WayPoint{
+		start,
+		{Latitude: end.Latitude, Longitude: end.Longitude, Timestamp: "2020-05-03T07:08:00Z"},
+	})
+
+	assert.InDelta(t, 1.0, gpx.DistanceMiles(), 1e-9)
+	assert.Equal(t, &Pace{8, 0}, gpx.PaceInMile())
+	assert.Equal(t, &Pace{4, 58}, gpx.PaceInKM())
+}
+
+func TestPaceWithoutDistance(t *testing.T) {
+	b := openGPX("_data/zero-duration.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, &Pace{}, gpx.PaceInKM())
+	assert.Equal(t, &Pace{}, gpx.PaceInMile())
+	assert.Equal(t, &Pace{}, (&GPX{}).PaceInKM())
+	assert.Equal(t, &Pace{}, (&GPX{}).PaceInMile())
+}
+
+func TestPaceWithoutDuration(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5},
+		{Latitude: 25.001, Longitude: 121.5},
+	})
+
+	assert.Equal(t, &Pace{}, gpx.PaceInKM())
+	assert.Equal(t, &Pace{}, gpx.PaceInMile())
 }
 
 func TestToRadians(t *testing.T) {
@@ -126,6 +224,54 @@ func TestMinAndMixElevation(t *testing.T) {
 	assert.Equal(t, 16.0, max)
 }
 
+func TestMinAndMaxElevation(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	min, max, ok := gpx.MinAndMaxElevation()
+
+	assert.True(t, ok)
+	assert.Equal(t, 14.8, min)
+	assert.Equal(t, 16.0, max)
+}
+
+func TestMinAndMaxElevationTwoSegments(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	min, max, ok := gpx.MinAndMaxElevation()
+
+	assert.True(t, ok)
+	assert.Equal(t, 10.0, min)
+	assert.Equal(t, 14.0, max)
+}
+
+func TestMinAndMaxElevationGap(t *testing.T) {
+	b := openGPX("_data/elevation-gap.gpx")
+	gpx, _ := ReadGPX(b)
+
+	min, max, ok := gpx.MinAndMaxElevation()
+
+	assert.True(t, ok)
+	assert.Equal(t, 100.0, min)
+	assert.Equal(t, 110.0, max)
+
+	_, _, ok = newTestGPX([]WayPoint{{Latitude: 25}, {Latitude: 25.001}}).MinAndMaxElevation()
+
+	assert.False(t, ok)
+}
+
+func TestMinAndMaxElevationEmpty(t *testing.T) {
+	gpx := &GPX{}
+
+	min, max, ok := gpx.MinAndMaxElevation()
+
+	assert.False(t, ok)
+	assert.Equal(t, 0.0, min)
+	assert.Equal(t, 0.0, max)
+	assert.NotPanics(t, func() { gpx.MinAndMixElevation() })
+}
+
 func TestGetCoordinates(t *testing.T) {
 	b := openGPX(testGPX)
 	gpx, _ := ReadGPX(b)
@@ -134,3 +280,603 @@ func TestGetCoordinates(t *testing.T) {
 
 	assert.Equal(t, 15, len(coordinates))
 }
+
+func TestReadWaypoints(t *testing.T) {
+	b := openGPX("_data/waypoints.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Len(t, gpx.Waypoints, 3)
+	assert.Len(t, gpx.Tracks, 0)
+	assert.Equal(t, "Taipei 101", gpx.Waypoints[0].Name)
+	assert.Equal(t, 25.033964, gpx.Waypoints[0].Latitude)
+	assert.Equal(t, 121.564472, gpx.Waypoints[0].Longitude)
+	assert.Equal(t, 8.0, gpx.Waypoints[1].Elevation)
+	assert.Equal(t, "Museum", gpx.Waypoints[2].Description)
+}
+
+func TestReadRoutes(t *testing.T) {
+	b := openGPX("_data/route.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Len(t, gpx.Routes, 1)
+
+	route := gpx.Routes[0]
+
+	assert.Equal(t, "Riverside Loop", route.Name)
+	assert.Equal(t, "Planned riverside running route", route.Description)
+	assert.Equal(t, 1, route.Number)
+	assert.Len(t, route.RoutePoints, 4)
+}
+
+func TestRouteDistance(t *testing.T) {
+	b := openGPX("_data/route.gpx")
+	gpx, _ := ReadGPX(b)
+
+	route := gpx.Routes[0]
+	expected := route.RoutePoints[0].Distance(&route.RoutePoints[1]) +
+		route.RoutePoints[1].Distance(&route.RoutePoints[2]) +
+		route.RoutePoints[2].Distance(&route.RoutePoints[3])
+
+	assert.Equal(t, expected, route.Distance())
+	assert.InDelta(t, 3.0, route.Distance(), 0.1)
+}
+
+func TestRouteElevationsAndCoordinates(t *testing.T) {
+	b := openGPX("_data/route.gpx")
+	gpx, _ := ReadGPX(b)
+
+	route := gpx.Routes[0]
+
+	assert.Equal(t, []float64{5.0, 7.0, 6.0, 4.0}, route.Elevations())
+	assert.Equal(t, Point{Latitude: 25.059, Longitude: 121.51}, route.GetCoordinates()[2])
+}
+
+func TestRouteToTrack(t *testing.T) {
+	b := openGPX("_data/route.gpx")
+	gpx, _ := ReadGPX(b)
+
+	route := &gpx.Routes[0]
+	track := route.ToTrack()
+
+	assert.Equal(t, "Riverside Loop", track.Name)
+	assert.Equal(t, "Planned riverside running route", track.Description)
+	assert.Equal(t, 1, track.Number)
+	assert.Len(t, track.TrackSegments, 1)
+	assert.Equal(t, route.RoutePoints, track.TrackSegments[0].TrackPoint)
+	assert.Equal(t, route.Distance(), track.Length())
+
+	track.TrackSegments[0].TrackPoint[0].Latitude = 0
+
+	assert.Equal(t, 25.05, route.RoutePoints[0].Latitude)
+	assert.Len(t, (&Route{}).ToTrack().TrackSegments, 0)
+}
+
+func TestNumPointsAndSegments(t *testing.T) {
+	b := openGPX("_data/sparse-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, 5, gpx.NumPoints())
+	assert.Equal(t, 4, gpx.NumSegments())
+	assert.Equal(t, 0, gpx.NumRoutePoints())
+
+	b = openGPX("_data/route.gpx")
+	gpx, _ = ReadGPX(b)
+
+	assert.Equal(t, 0, gpx.NumPoints())
+	assert.Equal(t, 0, gpx.NumSegments())
+	assert.Equal(t, len(gpx.Routes[0].RoutePoints), gpx.NumRoutePoints())
+	assert.Equal(t, 0, (&GPX{}).NumPoints())
+}
+
+func TestAppendPoint(t *testing.T) {
+	gpx := &GPX{}
+
+	gpx.AppendPoint(WayPoint{Latitude: 25, Longitude: 121.5})
+	gpx.AppendPoint(WayPoint{Latitude: 25.001, Longitude: 121.5})
+
+	assert.Len(t, gpx.Tracks, 1)
+	assert.Len(t, gpx.Tracks[0].TrackSegments, 1)
+	assert.Len(t, gpx.Points(), 2)
+
+	gpx.Tracks = append(gpx.Tracks, Track{Name: "Second"})
+	gpx.AppendPoint(WayPoint{Latitude: 25.002, Longitude: 121.5})
+
+	assert.Len(t, gpx.Tracks[1].TrackSegments, 1)
+	assert.Equal(t, 25.002, gpx.Tracks[1].TrackSegments[0].TrackPoint[0].Latitude)
+	assert.Len(t, gpx.Points(), 3)
+}
+
+func TestWalkSegments(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	var indexes [][2]int
+
+	gpx.WalkSegments(func(trackIdx, segIdx int, seg *TrackSegment) {
+		indexes = append(indexes, [2]int{trackIdx, segIdx})
+		seg.TrackPoint = seg.TrackPoint[1:]
+	})
+
+	assert.Equal(t, [][2]int{{0, 0}, {0, 1}}, indexes)
+	assert.Len(t, gpx.Points(), 4)
+}
+
+func TestWalkPoints(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	var indexes [][3]int
+
+	gpx.WalkPoints(func(trackIdx, segIdx, pointIdx int, p *WayPoint) {
+		indexes = append(indexes, [3]int{trackIdx, segIdx, pointIdx})
+		p.Elevation++
+	})
+
+	assert.Equal(t, [][3]int{{0, 0, 0}, {0, 0, 1}, {0, 0, 2}, {0, 1, 0}, {0, 1, 1}, {0, 1, 2}}, indexes)
+	assert.Equal(t, []float64{11, 12, 13, 15, 14, 13}, gpx.Elevations())
+}
+
+func TestGPXDistanceTwoSegments(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	var expected float64
+
+	for _, segment := range gpx.Tracks[0].TrackSegments {
+		points := segment.TrackPoint
+
+		for i := 1; i < len(points); i++ {
+			expected += points[i-1].Distance(&points[i])
+		}
+	}
+
+	assert.Len(t, gpx.Tracks[0].TrackSegments, 2)
+	assert.InDelta(t, expected, gpx.Distance(), 1e-12)
+	assert.InDelta(t, 0.4448, gpx.Distance(), 0.001)
+}
+
+func TestGPXDistanceConnected(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	points := gpx.Points()
+	gap := points[2].Distance(&points[3])
+
+	assert.InDelta(t, gpx.Distance()+gap, gpx.DistanceConnected(), 1e-12)
+	assert.Equal(t, 0.0, (&GPX{}).DistanceConnected())
+
+	b = openGPX(testGPX)
+	gpx, _ = ReadGPX(b)
+
+	assert.InDelta(t, gpx.Distance(), gpx.DistanceConnected(), 1e-12)
+}
+
+func TestTrackAndSegmentDuration(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	track := &gpx.Tracks[0]
+
+	assert.Equal(t, 60.0, track.TrackSegments[0].Duration())
+	assert.Equal(t, 60.0, track.TrackSegments[1].Duration())
+	assert.Equal(t, 360.0, track.Duration())
+	assert.Equal(t, gpx.Duration(), track.Duration())
+}
+
+func TestTrackAndSegmentDurationWithoutTimestamps(t *testing.T) {
+	segment := TrackSegment{TrackPoint: []WayPoint{
+		{Latitude: 25, Longitude: 121.5},
+		{Latitude: 25.001, Longitude: 121.5, Timestamp: "2020-05-03T07:00:00Z"},
+		{Latitude: 25.002, Longitude: 121.5, Timestamp: "2020-05-03T07:00:45Z"},
+		{Latitude: 25.003, Longitude: 121.5},
+	}}
+
+	assert.Equal(t, 45.0, segment.Duration())
+	assert.Equal(t, 45.0, (&Track{TrackSegments: []TrackSegment{segment, {}}}).Duration())
+	assert.Equal(t, 0.0, (&TrackSegment{}).Duration())
+	assert.Equal(t, 0.0, (&Track{}).Duration())
+	assert.Equal(t, 0.0, (&TrackSegment{TrackPoint: segment.TrackPoint[:2]}).Duration())
+}
+
+func TestSparseSegments(t *testing.T) {
+	b := openGPX("_data/sparse-segments.gpx")
+	gpx, err := ReadGPX(b)
+
+	assert.NoError(t, err)
+	assert.Len(t, gpx.Tracks, 2)
+	assert.Len(t, gpx.Tracks[0].TrackSegments, 4)
+
+	normal := &gpx.Tracks[0].TrackSegments[2]
+
+	assert.Equal(t, 0.0, gpx.Tracks[0].TrackSegments[0].Length())
+	assert.Equal(t, 0.0, gpx.Tracks[0].TrackSegments[1].Length())
+	assert.Equal(t, 0.0, gpx.Tracks[0].TrackSegments[0].Duration())
+	assert.Equal(t, 0.0, gpx.Tracks[0].TrackSegments[1].Duration())
+	assert.Equal(t, 60.0, normal.Duration())
+	assert.Equal(t, normal.Length(), gpx.Distance())
+	assert.Equal(t, 0.0, gpx.Tracks[1].Length())
+	assert.Equal(t, 0.0, gpx.Tracks[1].Duration())
+	assert.Equal(t, 180.0, gpx.Duration())
+	assert.Equal(t, 3.0, gpx.ElevationGain())
+	assert.Len(t, gpx.Speeds(), 2)
+	assert.Len(t, gpx.Grades(), 2)
+	assert.Len(t, gpx.CumulativeDistances(), 5)
+	assert.Equal(t, 60.0, gpx.MovingTime())
+
+	stats := gpx.Stats()
+
+	assert.Equal(t, gpx.Distance(), stats.TotalDistance)
+	assert.Equal(t, gpx.Duration(), stats.Duration)
+	assert.Equal(t, 5, stats.PointCount)
+
+	assert.NotPanics(t, func() {
+		gpx.Distance3D()
+		gpx.DistanceApprox()
+		gpx.DistanceConnected()
+		gpx.Bounds()
+		gpx.NearestPoint(Point{Latitude: 25, Longitude: 121.5})
+		gpx.PointAtDistance(0.1)
+		gpx.PointsInPolygon([]Point{{Latitude: 24, Longitude: 121}, {Latitude: 26, Longitude: 121}, {Latitude: 26, Longitude: 122}})
+		gpx.PaceInKM()
+		gpx.PaceInMile()
+		gpx.GradeAdjustedPace()
+		gpx.MinAndMaxElevation()
+		gpx.AverageSpeed()
+		gpx.MaxSpeed()
+		gpx.MovingDistance()
+		gpx.StoppedTime()
+		gpx.VAM()
+		gpx.Splits(0.1)
+		gpx.DetectLaps(50)
+		gpx.EstimateCalories(70, 30, true)
+		gpx.ElevationProfile()
+		gpx.Stats()
+		gpx.Simplify(1)
+		gpx.RemoveDuplicates()
+		gpx.RemoveOutliers(10)
+		gpx.SmoothElevation(3)
+		gpx.FillElevationGaps()
+		gpx.TrimStationaryEnds(1)
+		gpx.SplitOnGaps(time.Second, 10)
+		gpx.FlattenSegments()
+		gpx.Reverse()
+		gpx.ResampleByTime(10 * time.Second)
+		gpx.ResampleByDistance(10)
+		gpx.Clone()
+		gpx.Validate()
+		gpx.EncodePolyline(5)
+
+		_, _ = gpx.ToGeoJSON()
+		_, _ = gpx.ToKML()
+		_, _ = WriteGPXBytes(gpx)
+	})
+}
+
+func TestDurationWithoutTrackPoints(t *testing.T) {
+	assert.Equal(t, 0.0, (&GPX{}).Duration())
+	assert.Equal(t, 0.0, (&GPX{Tracks: []Track{{}}}).Duration())
+	assert.Equal(t, 0.0, (&GPX{Tracks: []Track{{TrackSegments: []TrackSegment{{}}}}}).Duration())
+
+	b := openGPX("_data/waypoints.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, 0.0, gpx.Duration())
+}
+
+func TestDurationSinglePoint(t *testing.T) {
+	gpx := &GPX{
+		Tracks: []Track{{
+			TrackSegments: []TrackSegment{{
+				TrackPoint: []WayPoint{{Timestamp: "2019-10-26T21:21:11Z"}},
+			}},
+		}},
+	}
+
+	assert.Equal(t, 0.0, gpx.Duration())
+}
+
+func TestDurationTwoSegments(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, 360.0, gpx.Duration())
+}
+
+func TestPoints(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	points := gpx.Points()
+
+	assert.Len(t, points, 6)
+	assert.Equal(t, gpx.Tracks[0].TrackSegments[0].TrackPoint[0], points[0])
+	assert.Equal(t, gpx.Tracks[0].TrackSegments[1].TrackPoint[0], points[3])
+	assert.Equal(t, gpx.Tracks[0].TrackSegments[1].TrackPoint[2], points[5])
+	assert.Empty(t, (&GPX{}).Points())
+}
+
+func TestReadGPXBytes(t *testing.T) {
+	b, _ := ioutil.ReadFile(testGPX)
+	gpx, err := ReadGPXBytes(b)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "StravaGPX", gpx.Creator)
+	assert.Equal(t, 34.0, gpx.Duration())
+
+	latin1 := []byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><gpx version=\"1.1\"><trk><name>Caf\xe9</name></trk></gpx>")
+	gpx, err = ReadGPXBytes(latin1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Café", gpx.Tracks[0].Name)
+
+	_, err = ReadGPXBytes([]byte("<gpx>"))
+
+	assert.Error(t, err)
+}
+
+func TestReadGPXFile(t *testing.T) {
+	gpx, err := ReadGPXFile(testGPX)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "StravaGPX", gpx.Creator)
+	assert.Equal(t, 34.0, gpx.Duration())
+}
+
+func TestReadGPXFileGzip(t *testing.T) {
+	gpx, err := ReadGPXFile(testGPX + ".gz")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "StravaGPX", gpx.Creator)
+	assert.Equal(t, 34.0, gpx.Duration())
+	assert.Len(t, gpx.Points(), 15)
+}
+
+func TestReadGPXFileGzipMagicBytes(t *testing.T) {
+	f, err := ioutil.TempFile("", "gpx")
+
+	assert.NoError(t, err)
+
+	defer os.Remove(f.Name())
+
+	b, _ := ioutil.ReadFile(testGPX + ".gz")
+	f.Write(b)
+	f.Close()
+
+	gpx, err := ReadGPXFile(f.Name())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "StravaGPX", gpx.Creator)
+}
+
+func TestReadGPXFileNotExist(t *testing.T) {
+	_, err := ReadGPXFile("_data/not-exist.gpx")
+
+	assert.Error(t, err)
+}
+
+func TestReadMetadata(t *testing.T) {
+	b := openGPX("_data/metadata.gpx")
+	gpx, _ := ReadGPX(b)
+
+	metadata := gpx.Metadata
+
+	assert.Equal(t, "Elephant Mountain Hike", metadata.Name)
+	assert.Equal(t, "Short hike up Elephant Mountain in Taipei", metadata.Description)
+	assert.Equal(t, "hiking, taipei", metadata.Keywords)
+	assert.Equal(t, "2020-05-04T09:00:00Z", metadata.Timestamp)
+	assert.Len(t, metadata.Links, 1)
+	assert.Equal(t, "https://www.openstreetmap.org/", metadata.Links[0].URL)
+
+	assert.Equal(t, "Peng Jie", metadata.Author.Name)
+	assert.Equal(t, &Email{ID: "neighborhood999", Domain: "example.com"}, metadata.Author.Email)
+	assert.Equal(t, "https://github.com/neighborhood999", metadata.Author.Link.URL)
+	assert.Equal(t, "neighborhood999", metadata.Author.Link.Text)
+
+	assert.Equal(t, &Copyright{
+		Author:  "OpenStreetMap contributors",
+		Year:    "2020",
+		License: "https://opendatacommons.org/licenses/odbl/",
+	}, metadata.Copyright)
+
+	assert.Equal(t, &Bounds{
+		MinLatitude:  25.0265,
+		MinLongitude: 121.57,
+		MaxLatitude:  25.0285,
+		MaxLongitude: 121.576,
+	}, metadata.Bounds)
+}
+
+func TestMetaDataTime(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	expectedTime := time.Date(2019, 10, 26, 21, 21, 11, 0, time.UTC)
+
+	assert.Equal(t, expectedTime, gpx.Metadata.Time())
+}
+
+func TestMetaDataTimeFractionalSeconds(t *testing.T) {
+	metadata := &MetaData{Timestamp: "2019-10-26T21:21:11.250Z"}
+	expectedTime := time.Date(2019, 10, 26, 21, 21, 11, 250000000, time.UTC)
+
+	assert.Equal(t, expectedTime, metadata.Time())
+}
+
+func TestMetaDataTimeInvalid(t *testing.T) {
+	metadata := &MetaData{Timestamp: "yesterday"}
+
+	assert.True(t, metadata.Time().IsZero())
+	assert.True(t, (&MetaData{}).Time().IsZero())
+}
+
+func TestReadGPXNamespaces(t *testing.T) {
+	b := openGPX("_data/garmin-tpx.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, map[string]string{
+		"":    GPXNamespace,
+		"ns2": GpxExtensionsNamespace,
+		"ns3": TrackPointExtensionNamespace,
+		"xsi": XSINamespace,
+	}, gpx.Namespaces)
+	assert.Nil(t, (&GPX{}).Namespaces)
+}
+
+func TestReadGPXMissingElevation(t *testing.T) {
+	b := openGPX("_data/missing-elevation.gpx")
+	gpx, err := ReadGPX(b)
+
+	assert.NoError(t, err)
+
+	points := gpx.Points()
+
+	assert.True(t, points[0].ElevationSet)
+	assert.True(t, points[0].HasElevation())
+	assert.Equal(t, 0.0, points[0].Elevation)
+	assert.False(t, points[1].ElevationSet)
+	assert.False(t, points[1].HasElevation())
+	assert.True(t, points[2].HasElevation())
+	assert.Equal(t, 4.0, points[2].Elevation)
+	assert.True(t, (&WayPoint{Elevation: 10}).HasElevation())
+}
+
+func TestReadGPX10(t *testing.T) {
+	b := openGPX("_data/gpx10.gpx")
+	gpx, err := ReadGPX(b)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0", gpx.Version)
+
+	metadata := gpx.Metadata
+
+	assert.Equal(t, "Legacy Ride", metadata.Name)
+	assert.Equal(t, "Morning ride exported from an old Garmin unit", metadata.Description)
+	assert.Equal(t, "Peng Jie", metadata.Author.Name)
+	assert.Equal(t, &Email{ID: "neighborhood999", Domain: "example.com"}, metadata.Author.Email)
+	assert.Equal(t, []Link{{URL: "https://github.com/neighborhood999", Text: "neighborhood999"}}, metadata.Links)
+	assert.Equal(t, "2008-06-01T06:00:00Z", metadata.Timestamp)
+	assert.Equal(t, "cycling", metadata.Keywords)
+	assert.Equal(t, 25.002, metadata.Bounds.MaxLatitude)
+
+	points := gpx.Points()
+
+	assert.Len(t, points, 3)
+	assert.Equal(t, 5.5, points[0].Speed)
+	assert.Equal(t, Degrees(0.5), points[1].Course)
+	assert.Equal(t, 40.0, gpx.Duration())
+}
+
+func TestReadGPX11IgnoresGPX10Elements(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, "", gpx.Metadata.Name)
+	assert.Nil(t, gpx.Metadata.Author)
+}
+
+func TestReadTrackPointExtension(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	extension := gpx.Points()[0].Extensions.TrackPointExtensions
+
+	assert.Equal(t, TrackPointExtensionNamespace, extension.XMLName.Space)
+	assert.Equal(t, 28.0, extension.Temperature)
+	assert.Equal(t, 104, extension.HeartRate)
+	assert.Equal(t, 61, extension.Cadence)
+}
+
+func TestReadTrackPointExtensionPrefixes(t *testing.T) {
+	b := openGPX("_data/garmin-tpx.gpx")
+	gpx, _ := ReadGPX(b)
+
+	points := gpx.Points()
+
+	assert.Len(t, points, 4)
+
+	v1 := points[1].Extensions.TrackPointExtensions
+
+	assert.Equal(t, TrackPointExtensionNamespace, v1.XMLName.Space)
+	assert.Equal(t, 24.0, v1.Temperature)
+	assert.Equal(t, 112, v1.HeartRate)
+	assert.Equal(t, 84, v1.Cadence)
+
+	v2 := points[2].Extensions.TrackPointExtensions
+
+	assert.Equal(t, TrackPointExtensionV2Namespace, v2.XMLName.Space)
+	assert.Equal(t, 23.0, v2.Temperature)
+	assert.Equal(t, 125, v2.HeartRate)
+	assert.Equal(t, 86, v2.Cadence)
+
+	assert.Nil(t, points[3].Extensions)
+}
+
+// cancelReader cancels its context once n bytes have been read.
+type cancelReader struct {
+	r      io.Reader
+	n      int
+	cancel context.CancelFunc
+}
+
+func (cr *cancelReader) Read(p []byte) (int, error) {
+	if len(p) > 64 {
+		p = p[:64]
+	}
+
+	n, err := cr.r.Read(p)
+	cr.n -= n
+
+	if cr.n <= 0 {
+		cr.cancel()
+	}
+
+	return n, err
+}
+
+func TestReadGPXContext(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, err := ReadGPXContext(context.Background(), b)
+
+	assert.NoError(t, err)
+	assert.Len(t, gpx.Points(), 15)
+}
+
+func TestReadGPXContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	b := openGPX(testGPX)
+	_, err := ReadGPXContext(ctx, b)
+
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestReadGPXContextCancelledWhileReading(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := openGPX(testGPX)
+	_, err := ReadGPXContext(ctx, &cancelReader{r: b, n: 1024, cancel: cancel})
+
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestTrackAndSegmentLength(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	track := gpx.Tracks[0]
+	first := track.TrackSegments[0]
+	second := track.TrackSegments[1]
+
+	assert.InDelta(t, 0.2224, first.Length(), 0.0001)
+	assert.InDelta(t, 0.2224, second.Length(), 0.0001)
+	assert.Equal(t, first.Length()+second.Length(), track.Length())
+	assert.Equal(t, track.Length(), gpx.Distance())
+
+	merged := MergeGPX(gpx, gpx)
+
+	assert.Equal(t, 2*track.Length(), merged.Distance())
+	assert.Equal(t, 0.0, (&Track{}).Length())
+	assert.Equal(t, 0.0, (&TrackSegment{}).Length())
+}
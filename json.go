@@ -0,0 +1,21 @@
+package gpx
+
+import "encoding/json"
+
+// MarshalJSON encodes the raw XML of the extensions as a JSON string.
+func (e Extensions) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(e.XML))
+}
+
+// UnmarshalJSON decodes the raw XML of the extensions from a JSON string.
+func (e *Extensions) UnmarshalJSON(data []byte) error {
+	var s string
+
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	e.XML = []byte(s)
+
+	return nil
+}
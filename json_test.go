@@ -0,0 +1,45 @@
+package gpx
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	data, err := json.Marshal(gpx)
+
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "XMLName")
+	assert.Contains(t, string(data), `"tracks":[{"name":"Strava Running Sample"`)
+	assert.Contains(t, string(data), `"heartRate":104`)
+
+	decoded := &GPX{}
+
+	assert.Nil(t, json.Unmarshal(data, decoded))
+	assert.Equal(t, gpx.Creator, decoded.Creator)
+	assert.Equal(t, gpx.Metadata.Timestamp, decoded.Metadata.Timestamp)
+	assert.Equal(t, gpx.Tracks[0].Name, decoded.Tracks[0].Name)
+	assert.Len(t, decoded.Tracks[0].TrackSegments, len(gpx.Tracks[0].TrackSegments))
+	assert.Equal(t, len(gpx.Points()), len(decoded.Points()))
+	assert.Equal(t, gpx.Points()[0].Latitude, decoded.Points()[0].Latitude)
+	assert.Equal(t, gpx.Points()[0].Extensions.TrackPointExtensions.HeartRate, decoded.Points()[0].Extensions.TrackPointExtensions.HeartRate)
+	assert.Equal(t, gpx.Distance(), decoded.Distance())
+}
+
+func TestJSONExtensions(t *testing.T) {
+	track := Track{Extensions: &Extensions{XML: []byte("<custom>1</custom>")}}
+	data, err := json.Marshal(track)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `{"extensions":"\u003ccustom\u003e1\u003c/custom\u003e"}`, string(data))
+
+	decoded := Track{}
+
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, track.Extensions, decoded.Extensions)
+}
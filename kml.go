@@ -0,0 +1,105 @@
+package gpx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// KMLNamespace is the KML 2.2 XML namespace.
+const KMLNamespace = "http://www.opengis.net/kml/2.2"
+
+// kml is the representation of a KML document.
+// ref: https://developers.google.com/kml/documentation/kmlreference
+type kml struct {
+	XMLName  xml.Name    `xml:"kml"`
+	XMLNS    string      `xml:"xmlns,attr"`
+	Document kmlDocument `xml:"Document"`
+}
+
+// kmlDocument is the representation of a KML Document.
+type kmlDocument struct {
+	Name        string         `xml:"name,omitempty"`
+	Description string         `xml:"description,omitempty"`
+	Placemarks  []kmlPlacemark `xml:"Placemark"`
+}
+
+// kmlPlacemark is the representation of a KML Placemark.
+type kmlPlacemark struct {
+	Name          string            `xml:"name,omitempty"`
+	Description   string            `xml:"description,omitempty"`
+	Point         *kmlCoordinates   `xml:"Point,omitempty"`
+	LineString    *kmlCoordinates   `xml:"LineString,omitempty"`
+	MultiGeometry *kmlMultiGeometry `xml:"MultiGeometry,omitempty"`
+}
+
+// kmlMultiGeometry is the representation of a KML MultiGeometry of LineStrings.
+type kmlMultiGeometry struct {
+	LineStrings []kmlCoordinates `xml:"LineString"`
+}
+
+// kmlCoordinates is the representation of a KML geometry coordinates.
+type kmlCoordinates struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+// ToKML returns the GPX as a KML document. Every track is a Placemark with a
+// LineString, or a MultiGeometry of LineStrings when it has more than one
+// segment, and every waypoint is a Placemark with a Point.
+func (g *GPX) ToKML() ([]byte, error) {
+	document := kml{XMLNS: KMLNamespace}
+
+	if g.Metadata != nil {
+		document.Document.Name = g.Metadata.Name
+		document.Document.Description = g.Metadata.Description
+	}
+
+	for _, track := range g.Tracks {
+		placemark := kmlPlacemark{Name: track.Name, Description: track.Description}
+		lineStrings := make([]kmlCoordinates, len(track.TrackSegments))
+
+		for i, segment := range track.TrackSegments {
+			lineStrings[i] = kmlCoordinates{Coordinates: kmlCoordinatesString(segment.TrackPoint)}
+		}
+
+		if len(lineStrings) == 1 {
+			placemark.LineString = &lineStrings[0]
+		} else {
+			placemark.MultiGeometry = &kmlMultiGeometry{LineStrings: lineStrings}
+		}
+
+		document.Document.Placemarks = append(document.Document.Placemarks, placemark)
+	}
+
+	for _, waypoint := range g.Waypoints {
+		document.Document.Placemarks = append(document.Document.Placemarks, kmlPlacemark{
+			Name:        waypoint.Name,
+			Description: waypoint.Description,
+			Point:       &kmlCoordinates{Coordinates: kmlCoordinatesString([]WayPoint{waypoint})},
+		})
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString(xml.Header)
+
+	if err := xml.NewEncoder(&buf).Encode(document); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// kmlCoordinatesString returns the points as space separated lon,lat,ele tuples.
+func kmlCoordinatesString(points []WayPoint) string {
+	tuples := make([]string, len(points))
+
+	for i, point := range points {
+		tuples[i] = strconv.FormatFloat(point.Longitude, 'f', -1, 64) + "," +
+			strconv.FormatFloat(point.Latitude, 'f', -1, 64) + "," +
+			strconv.FormatFloat(point.Elevation, 'f', -1, 64)
+	}
+
+	return strings.Join(tuples, " ")
+}
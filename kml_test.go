@@ -0,0 +1,71 @@
+package gpx
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToKML(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	output, err := gpx.ToKML()
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(output), xml.Header))
+
+	var document kml
+	assert.NoError(t, xml.Unmarshal(output, &document))
+
+	assert.Equal(t, KMLNamespace, document.XMLName.Space)
+	assert.Len(t, document.Document.Placemarks, 1)
+
+	placemark := document.Document.Placemarks[0]
+
+	assert.Equal(t, "Strava Running Sample", placemark.Name)
+	assert.NotNil(t, placemark.LineString)
+
+	coordinates := strings.Fields(placemark.LineString.Coordinates)
+
+	assert.Len(t, coordinates, 15)
+	assert.Equal(t, "121.516609,25.039374,15.4", coordinates[0])
+}
+
+func TestToKMLMultipleSegmentsAndWaypoints(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	w := openGPX("_data/waypoints.gpx")
+	waypoints, _ := ReadGPX(w)
+
+	gpx.Waypoints = waypoints.Waypoints
+
+	output, err := gpx.ToKML()
+
+	assert.NoError(t, err)
+
+	var document kml
+	assert.NoError(t, xml.Unmarshal(output, &document))
+
+	placemarks := document.Document.Placemarks
+
+	assert.Len(t, placemarks, 4)
+	assert.Nil(t, placemarks[0].LineString)
+	assert.Len(t, placemarks[0].MultiGeometry.LineStrings, 2)
+	assert.Equal(t, "Taipei 101", placemarks[1].Name)
+	assert.Equal(t, "121.564472,25.033964,10", placemarks[1].Point.Coordinates)
+	assert.Equal(t, "Museum", placemarks[3].Description)
+}
+
+func TestToKMLMetadata(t *testing.T) {
+	b := openGPX("_data/metadata.gpx")
+	gpx, _ := ReadGPX(b)
+
+	output, err := gpx.ToKML()
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(output), "<Document><name>Elephant Mountain Hike</name>")
+}
@@ -0,0 +1,100 @@
+package gpx
+
+import (
+	"math"
+	"strings"
+)
+
+// EncodePolyline returns every track point encoded with the Google encoded
+// polyline algorithm, precision is the number of decimal places kept (5 or 6).
+// ref: https://developers.google.com/maps/documentation/utilities/polylinealgorithm
+func (g *GPX) EncodePolyline(precision int) string {
+	return encodePolyline(g.GetCoordinates(), precision)
+}
+
+// DecodePolyline returns the points of a Google encoded polyline,
+// precision is the number of decimal places it was encoded with.
+func DecodePolyline(s string, precision int) []Point {
+	factor := math.Pow10(precision)
+	points := []Point{}
+
+	var lat, lon int64
+
+	for i := 0; i < len(s); {
+		var deltaLat, deltaLon int64
+
+		deltaLat, i = decodePolylineValue(s, i)
+		deltaLon, i = decodePolylineValue(s, i)
+
+		lat += deltaLat
+		lon += deltaLon
+
+		points = append(points, Point{
+			Latitude:  float64(lat) / factor,
+			Longitude: float64(lon) / factor,
+		})
+	}
+
+	return points
+}
+
+// encodePolyline encodes the points with the Google encoded polyline algorithm.
+func encodePolyline(points []Point, precision int) string {
+	var sb strings.Builder
+	var lastLat, lastLon int64
+
+	factor := math.Pow10(precision)
+
+	for _, point := range points {
+		lat := int64(math.Round(point.Latitude * factor))
+		lon := int64(math.Round(point.Longitude * factor))
+
+		encodePolylineValue(&sb, lat-lastLat)
+		encodePolylineValue(&sb, lon-lastLon)
+
+		lastLat, lastLon = lat, lon
+	}
+
+	return sb.String()
+}
+
+// encodePolylineValue writes a single signed value as polyline chunks.
+func encodePolylineValue(sb *strings.Builder, value int64) {
+	v := value << 1
+
+	if value < 0 {
+		v = ^v
+	}
+
+	for v >= 0x20 {
+		sb.WriteByte(byte((0x20 | (v & 0x1f)) + 63))
+		v >>= 5
+	}
+
+	sb.WriteByte(byte(v + 63))
+}
+
+// decodePolylineValue reads a single signed value starting at index i
+// and returns it with the index of the next value.
+func decodePolylineValue(s string, i int) (int64, int) {
+	var result int64
+	var shift uint
+
+	for i < len(s) {
+		b := int64(s[i]) - 63
+		i++
+
+		result |= (b & 0x1f) << shift
+		shift += 5
+
+		if b < 0x20 {
+			break
+		}
+	}
+
+	if result&1 != 0 {
+		return ^(result >> 1), i
+	}
+
+	return result >> 1, i
+}
@@ -0,0 +1,56 @@
+package gpx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// googlePolyline is the example of the Google encoded polyline algorithm documentation.
+const googlePolyline = "_p~iF~ps|U_ulLnnqC_mqNvxq`@"
+
+func TestEncodePolyline(t *testing.T) {
+	gpx := &GPX{
+		Tracks: []Track{{
+			TrackSegments: []TrackSegment{{
+				TrackPoint: []WayPoint{
+					{Latitude: 38.5, Longitude: -120.2},
+					{Latitude: 40.7, Longitude: -120.95},
+					{Latitude: 43.252, Longitude: -126.453},
+				},
+			}},
+		}},
+	}
+
+	assert.Equal(t, googlePolyline, gpx.EncodePolyline(5))
+}
+
+func TestDecodePolyline(t *testing.T) {
+	points := DecodePolyline(googlePolyline, 5)
+
+	assert.Equal(t, []Point{
+		{Latitude: 38.5, Longitude: -120.2},
+		{Latitude: 40.7, Longitude: -120.95},
+		{Latitude: 43.252, Longitude: -126.453},
+	}, points)
+}
+
+func TestPolylineRoundTrip(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	coordinates := gpx.GetCoordinates()
+	points := DecodePolyline(gpx.EncodePolyline(6), 6)
+
+	assert.Len(t, points, len(coordinates))
+
+	for i := range points {
+		assert.InDelta(t, coordinates[i].Latitude, points[i].Latitude, 1e-6)
+		assert.InDelta(t, coordinates[i].Longitude, points[i].Longitude, 1e-6)
+	}
+}
+
+func TestDecodePolylineEmpty(t *testing.T) {
+	assert.Empty(t, DecodePolyline("", 5))
+	assert.Equal(t, "", (&GPX{}).EncodePolyline(5))
+}
@@ -0,0 +1,1042 @@
+package gpx
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+var (
+	// StoppedSpeedThreshold is the speed in m/s below which the move between
+	// two consecutive track points counts as stopped.
+	StoppedSpeedThreshold = 0.8
+
+	// MaxMovingGap is the time gap between two consecutive track points above
+	// which the move between them counts as stopped.
+	MaxMovingGap = time.Minute
+)
+
+// AnySpeed is a MovingOptions.StoppedSpeed counting a move at any speed,
+// 0 m/s included, as moving, as the zero StoppedSpeed takes the default.
+const AnySpeed = -1.0
+
+// MovingOptions configures when the move between two consecutive track points
+// counts as moving, for MovingTimeOptions, MovingDistanceOptions,
+// StoppedTimeOptions, StatsOptions, StopsOptions and
+// TrimStationaryEndsOptions. A zero field takes the value of its package
+// default.
+type MovingOptions struct {
+	// StoppedSpeed is the speed in m/s below which the move counts as
+	// stopped, StoppedSpeedThreshold (0.8 m/s) by default. Set it to
+	// AnySpeed for a threshold of 0.
+	StoppedSpeed float64
+
+	// MaxGap is the time gap above which the move counts as stopped,
+	// MaxMovingGap (1 minute) by default.
+	MaxGap time.Duration
+}
+
+// withDefaults returns the options with the package defaults set for the
+// zero fields.
+func (o MovingOptions) withDefaults() MovingOptions {
+	if o.StoppedSpeed == 0 {
+		o.StoppedSpeed = StoppedSpeedThreshold
+	}
+
+	if o.MaxGap == 0 {
+		o.MaxGap = MaxMovingGap
+	}
+
+	return o
+}
+
+// moving returns the time gap from a to b, and whether the move between
+// them counts as moving.
+func (o MovingOptions) moving(a, b *WayPoint) (time.Duration, bool) {
+	start := a.Time()
+	end := b.Time()
+
+	if start.IsZero() || end.IsZero() || !end.After(start) {
+		return 0, false
+	}
+
+	gap := end.Sub(start)
+
+	return gap, o.isMoving(a.Distance(b)*1000/gap.Seconds(), gap)
+}
+
+// isMoving reports whether a move at speed m/s over the time gap counts as
+// moving.
+func (o MovingOptions) isMoving(speed float64, gap time.Duration) bool {
+	return speed >= o.StoppedSpeed && gap <= o.MaxGap
+}
+
+// ElevationGain returns the total ascent in meters of every track segment.
+func (g *GPX) ElevationGain() float64 {
+	gain, _ := g.ElevationChange(0)
+
+	return gain
+}
+
+// ElevationLoss returns the total descent in meters of every track segment.
+func (g *GPX) ElevationLoss() float64 {
+	_, loss := g.ElevationChange(0)
+
+	return loss
+}
+
+// ElevationGainFeet returns the total ascent in feet of every track segment.
+func (g *GPX) ElevationGainFeet() float64 {
+	return g.ElevationGain() / MetersPerFoot
+}
+
+// ElevationLossFeet returns the total descent in feet of every track segment.
+func (g *GPX) ElevationLossFeet() float64 {
+	return g.ElevationLoss() / MetersPerFoot
+}
+
+// ElevationChange returns the total ascent and descent in meters of every
+// track segment. An elevation change is only counted once it reaches the
+// threshold (in meters) from the last counted elevation, so a threshold of
+// about 1 meter filters out GPS noise. Both values are positive. The track
+// points without elevation (see HasElevation) are skipped.
+func (g *GPX) ElevationChange(threshold float64) (float64, float64) {
+	var gain, loss float64
+
+	for _, track := range g.Tracks {
+		for _, segment := range track.TrackSegments {
+			trackPoints := segment.TrackPoint
+			first := 0
+
+			for first < len(trackPoints) && !trackPoints[first].HasElevation() {
+				first++
+			}
+
+			if first == len(trackPoints) {
+				continue
+			}
+
+			reference := trackPoints[first].Elevation
+
+			for i := first + 1; i < len(trackPoints); i++ {
+				if !trackPoints[i].HasElevation() {
+					continue
+				}
+
+				delta := trackPoints[i].Elevation - reference
+
+				if delta == 0 || (delta < threshold && -delta < threshold) {
+					continue
+				}
+
+				if delta > 0 {
+					gain += delta
+				} else {
+					loss -= delta
+				}
+
+				reference = trackPoints[i].Elevation
+			}
+		}
+	}
+
+	return gain, loss
+}
+
+// AverageSpeed returns the average speed in km/h, the total distance divided
+// by the total duration. It returns 0 when the duration is 0.
+func (g *GPX) AverageSpeed() float64 {
+	duration := g.Duration()
+
+	if duration == 0 {
+		return 0
+	}
+
+	return g.Distance() / (duration / 3600)
+}
+
+// MaxSpeed returns the highest speed in km/h between two consecutive track
+// points. Point pairs without a positive time delta are skipped.
+func (g *GPX) MaxSpeed() float64 {
+	var maxSpeed float64
+
+	for _, speed := range g.Speeds() {
+		if speed > maxSpeed {
+			maxSpeed = speed
+		}
+	}
+
+	return maxSpeed * 3.6
+}
+
+// Speeds returns the speed in m/s between every two consecutive track points
+// of a segment, in order, so there is one speed less than points per segment.
+// Point pairs without a positive time delta have a speed of 0.
+func (g *GPX) Speeds() []float64 {
+	speeds := []float64{}
+
+	for _, track := range g.Tracks {
+		for _, segment := range track.TrackSegments {
+			trackPoints := segment.TrackPoint
+
+			for i := 1; i < len(trackPoints); i++ {
+				speeds = append(speeds, trackPoints[i-1].SpeedTo(&trackPoints[i]))
+			}
+		}
+	}
+
+	return speeds
+}
+
+// TimeGaps returns the time between every two consecutive track points of a
+// segment, in the order of Speeds, to find the recording dropouts to split
+// on with SplitOnGaps. Point pairs without a positive time delta, a
+// timestamp missing or unparseable included, have a gap of 0.
+func (g *GPX) TimeGaps() []time.Duration {
+	gaps := []time.Duration{}
+
+	for _, track := range g.Tracks {
+		for _, segment := range track.TrackSegments {
+			trackPoints := segment.TrackPoint
+
+			for i := 1; i < len(trackPoints); i++ {
+				gaps = append(gaps, timeDelta(&trackPoints[i-1], &trackPoints[i]))
+			}
+		}
+	}
+
+	return gaps
+}
+
+// timeDelta returns the time from a to b, 0 when a timestamp can't be parsed
+// or the time delta isn't positive.
+func timeDelta(a, b *WayPoint) time.Duration {
+	start, end := a.Time(), b.Time()
+
+	if start.IsZero() || end.IsZero() || !end.After(start) {
+		return 0
+	}
+
+	return end.Sub(start)
+}
+
+// SpeedBuckets partitions the track segments into runs of consecutive
+// points whose speed (see Speeds) stays in the same band, for drawing a
+// polyline colored by speed. The thresholds are ascending speeds in m/s
+// splitting the bands, a speed equal to a threshold belongs to the band
+// above it. The speed of a point pair decides the band of both points, so
+// two successive runs share their boundary point and draw a continuous line.
+// Runs don't span segments, and segments with fewer than two points are left
+// out.
+func (g *GPX) SpeedBuckets(thresholds []float64) [][]WayPoint {
+	buckets := [][]WayPoint{}
+
+	for _, track := range g.Tracks {
+		for _, segment := range track.TrackSegments {
+			trackPoints := segment.TrackPoint
+			band := -1
+
+			for i := 1; i < len(trackPoints); i++ {
+				speed := trackPoints[i-1].SpeedTo(&trackPoints[i])
+				pairBand := sort.SearchFloat64s(thresholds, speed)
+
+				if pairBand < len(thresholds) && thresholds[pairBand] == speed {
+					pairBand++
+				}
+
+				if pairBand != band {
+					buckets = append(buckets, []WayPoint{trackPoints[i-1]})
+					band = pairBand
+				}
+
+				buckets[len(buckets)-1] = append(buckets[len(buckets)-1], trackPoints[i])
+			}
+		}
+	}
+
+	return buckets
+}
+
+// MovingTime returns the time in seconds spent moving. The move between two
+// consecutive track points counts as moving when its speed is at least
+// StoppedSpeedThreshold and its time gap is at most MaxMovingGap.
+func (g *GPX) MovingTime() float64 {
+	return g.MovingTimeOptions(MovingOptions{})
+}
+
+// MovingTimeOptions is like MovingTime with the given options.
+func (g *GPX) MovingTimeOptions(opts MovingOptions) float64 {
+	opts = opts.withDefaults()
+
+	var movingTime float64
+
+	for _, track := range g.Tracks {
+		for _, segment := range track.TrackSegments {
+			trackPoints := segment.TrackPoint
+
+			for i := 1; i < len(trackPoints); i++ {
+				if gap, ok := opts.moving(&trackPoints[i-1], &trackPoints[i]); ok {
+					movingTime += gap.Seconds()
+				}
+			}
+		}
+	}
+
+	return movingTime
+}
+
+// MovingDistance returns the distance in kilometers covered while moving,
+// the moves counted by MovingTime. It leaves out the distance added by GPS
+// jitter while standing still.
+func (g *GPX) MovingDistance() float64 {
+	return g.MovingDistanceOptions(MovingOptions{})
+}
+
+// MovingDistanceOptions is like MovingDistance with the given options.
+func (g *GPX) MovingDistanceOptions(opts MovingOptions) float64 {
+	opts = opts.withDefaults()
+
+	var movingDistance float64
+
+	for _, track := range g.Tracks {
+		for _, segment := range track.TrackSegments {
+			trackPoints := segment.TrackPoint
+
+			for i := 1; i < len(trackPoints); i++ {
+				if _, ok := opts.moving(&trackPoints[i-1], &trackPoints[i]); ok {
+					movingDistance += trackPoints[i-1].Distance(&trackPoints[i])
+				}
+			}
+		}
+	}
+
+	return movingDistance
+}
+
+// StoppedTime returns the time in seconds spent stopped, the Duration minus
+// the MovingTime. The pauses between track segments count as stopped.
+func (g *GPX) StoppedTime() float64 {
+	return g.StoppedTimeOptions(MovingOptions{})
+}
+
+// StoppedTimeOptions is like StoppedTime with the given options.
+func (g *GPX) StoppedTimeOptions(opts MovingOptions) float64 {
+	stoppedTime := g.Duration() - g.MovingTimeOptions(opts)
+
+	if stoppedTime < 0 {
+		return 0
+	}
+
+	return stoppedTime
+}
+
+// VAM returns the mean ascent velocity, the elevation gain in meters
+// per hour of moving time. It returns 0 when there is no ascent or no moving
+// time.
+func (g *GPX) VAM() float64 {
+	gain := g.ElevationGain()
+	movingTime := g.MovingTime()
+
+	if gain == 0 || movingTime == 0 {
+		return 0
+	}
+
+	return gain / (movingTime / 3600)
+}
+
+// splitTolerance is the distance in kilometers under which a split boundary
+// is considered reached, it absorbs floating point errors.
+const splitTolerance = 1e-9
+
+// Split is the statistic of a successive part of the activity.
+type Split struct {
+	Distance float64 // kilometers
+	Duration float64 // seconds
+	Pace     Pace    // per kilometer
+}
+
+// Splits returns the successive splits of distanceKm kilometers, the last one
+// holding the remaining distance when it is shorter. The split boundaries are
+// interpolated between the two track points they fall between. Track points
+// without timestamp are skipped, and the gaps between track segments count
+// as time but not as distance.
+func (g *GPX) Splits(distanceKm float64) []Split {
+	splits := []Split{}
+
+	if distanceKm <= 0 {
+		return splits
+	}
+
+	var previous *WayPoint
+	var distance, lastDistance float64
+	var lastTime, splitStart time.Time
+
+	boundary := distanceKm
+
+	for i := range g.Tracks {
+		for j := range g.Tracks[i].TrackSegments {
+			trackPoints := g.Tracks[i].TrackSegments[j].TrackPoint
+			segmentStart := true
+
+			for k := range trackPoints {
+				point := &trackPoints[k]
+				pointTime := point.Time()
+
+				if pointTime.IsZero() {
+					continue
+				}
+
+				if previous == nil {
+					splitStart = pointTime
+				} else if !segmentStart {
+					distance += previous.Distance(point)
+				}
+
+				for distance+splitTolerance >= boundary && distance > lastDistance {
+					ratio := (boundary - lastDistance) / (distance - lastDistance)
+					boundaryTime := lastTime.Add(time.Duration(ratio * float64(pointTime.Sub(lastTime))))
+
+					splits = append(splits, newSplit(distanceKm, boundaryTime.Sub(splitStart).Seconds()))
+					splitStart = boundaryTime
+					boundary += distanceKm
+				}
+
+				previous = point
+				lastDistance = distance
+				lastTime = pointTime
+				segmentStart = false
+			}
+		}
+	}
+
+	remaining := distance - (boundary - distanceKm)
+
+	if previous != nil && remaining > splitTolerance {
+		splits = append(splits, newSplit(remaining, lastTime.Sub(splitStart).Seconds()))
+	}
+
+	return splits
+}
+
+// newSplit returns the split of the distance in kilometers run in duration seconds.
+func newSplit(distance, duration float64) Split {
+	return Split{
+		Distance: distance,
+		Duration: duration,
+		Pace:     *pace(duration, distance),
+	}
+}
+
+// Lap is the statistic of a lap of a loop activity.
+type Lap Split
+
+// DetectLaps returns the laps of a loop activity, a lap is completed every
+// time the path comes back within radiusMeters of the first track point
+// after having left it, so the initial departure is not a lap. The part after
+// the last completed lap is returned as a last lap when it leaves the radius.
+// The gaps between track segments count as time but not as distance.
+func (g *GPX) DetectLaps(radiusMeters float64) []Lap {
+	laps := []Lap{}
+	points := g.Points()
+
+	if len(points) == 0 {
+		return laps
+	}
+
+	start := &points[0]
+	radius := radiusMeters / 1000
+	lapStart := start.Time()
+	away := false
+
+	var distance float64
+	var previous *WayPoint
+
+	for i := range g.Tracks {
+		for j := range g.Tracks[i].TrackSegments {
+			trackPoints := g.Tracks[i].TrackSegments[j].TrackPoint
+
+			for k := range trackPoints {
+				point := &trackPoints[k]
+
+				if k > 0 {
+					distance += trackPoints[k-1].Distance(point)
+				}
+
+				previous = point
+
+				if start.Distance(point) > radius {
+					away = true
+					continue
+				}
+
+				if away {
+					laps = append(laps, newLap(distance, lapStart, point.Time()))
+					lapStart = point.Time()
+					distance = 0
+					away = false
+				}
+			}
+		}
+	}
+
+	if away {
+		laps = append(laps, newLap(distance, lapStart, previous.Time()))
+	}
+
+	return laps
+}
+
+// newLap returns the lap of the distance in kilometers run from start to end,
+// its duration is 0 when a timestamp is missing.
+func newLap(distance float64, start, end time.Time) Lap {
+	var duration float64
+
+	if !start.IsZero() && !end.IsZero() {
+		duration = end.Sub(start).Seconds()
+	}
+
+	return Lap(newSplit(distance, duration))
+}
+
+// Stop is a place where the activity paused.
+type Stop struct {
+	Center   Point     // average position of the stop track points
+	Start    time.Time // time of the first stop track point
+	Duration time.Duration
+}
+
+// Stops returns the places where the path stayed within radiusMeters of the
+// track point it arrived at for at least minDuration, in order. A stop lasts
+// from that first track point to the last successive one within the radius
+// reached by a move counting as stopped (see MovingTime), the next stop can't
+// start before it ends. The stops may span track segments, as a device
+// pausing the recording usually starts a new one, and track points without
+// timestamp can't start a stop.
+func (g *GPX) Stops(minDuration time.Duration, radiusMeters float64) []Stop {
+	return g.StopsOptions(minDuration, radiusMeters, MovingOptions{})
+}
+
+// StopsOptions is like Stops with the given moving options.
+func (g *GPX) StopsOptions(minDuration time.Duration, radiusMeters float64, opts MovingOptions) []Stop {
+	opts = opts.withDefaults()
+	stops := []Stop{}
+	points := g.Points()
+	radius := radiusMeters / 1000
+
+	for i := 0; i < len(points); i++ {
+		start := points[i].Time()
+
+		if start.IsZero() {
+			continue
+		}
+
+		last := i
+
+		for last+1 < len(points) && points[i].Distance(&points[last+1]) <= radius {
+			if _, moving := opts.moving(&points[last], &points[last+1]); moving {
+				break
+			}
+
+			last++
+		}
+
+		end := points[last].Time()
+
+		if end.IsZero() || end.Sub(start) < minDuration || last == i {
+			continue
+		}
+
+		var latitude, longitude float64
+
+		for _, point := range points[i : last+1] {
+			latitude += point.Latitude
+			longitude += point.Longitude
+		}
+
+		count := float64(last - i + 1)
+
+		stops = append(stops, Stop{
+			Center:   Point{Latitude: latitude / count, Longitude: longitude / count},
+			Start:    start,
+			Duration: end.Sub(start),
+		})
+		i = last
+	}
+
+	return stops
+}
+
+// AverageHeartRate returns the average heart rate of the track points
+// with a heart rate reading, 0 when there is none.
+func (g *GPX) AverageHeartRate() int {
+	var sum, count int
+
+	for _, hr := range g.heartRates() {
+		sum += hr
+		count++
+	}
+
+	if count == 0 {
+		return 0
+	}
+
+	return int(math.Round(float64(sum) / float64(count)))
+}
+
+// MaxHeartRate returns the highest heart rate of the track points, 0 when
+// there is no heart rate reading.
+func (g *GPX) MaxHeartRate() int {
+	var max int
+
+	for _, hr := range g.heartRates() {
+		if hr > max {
+			max = hr
+		}
+	}
+
+	return max
+}
+
+// MinHeartRate returns the lowest heart rate of the track points, 0 when
+// there is no heart rate reading.
+func (g *GPX) MinHeartRate() int {
+	var min int
+
+	for _, hr := range g.heartRates() {
+		if min == 0 || hr < min {
+			min = hr
+		}
+	}
+
+	return min
+}
+
+// heartRates returns the heart rate readings of every track point,
+// points without a reading are skipped.
+func (g *GPX) heartRates() []int {
+	var heartRates []int
+
+	for _, point := range g.Points() {
+		if extension := point.trackPointExtension(); extension != nil && extension.HeartRate > 0 {
+			heartRates = append(heartRates, extension.HeartRate)
+		}
+	}
+
+	return heartRates
+}
+
+// EstimateCalories returns a rough estimate of the energy expended in
+// kilocalories by a person of weightKg kilograms and age years.
+//
+// When the track points have heart rate readings, it uses the heart rate
+// prediction equations of Keytel et al. (2005) with the average heart rate
+// over the whole Duration:
+//
+//	male:   (-55.0969 + 0.6309 * hr + 0.1988 * weight + 0.2017 * age) / 4.184
+//	female: (-20.4022 + 0.4472 * hr - 0.1263 * weight + 0.074 * age) / 4.184
+//
+// in kilocalories per minute. Otherwise it falls back on a MET estimate from
+// the average speed while moving, MovingDistance over MovingTime, with the
+// VO2 of the ACSM metabolic equations (3.5 + 0.1 * speed ml/kg/min walking,
+// 3.5 + 0.2 * speed running above 8 km/h, speed in m/min) and 1 MET =
+// 3.5 ml/kg/min, that is about 1 kcal per kilogram and hour. Both assume a
+// steady effort on flat ground, and the age and sex are only used by the
+// heart rate equations.
+func (g *GPX) EstimateCalories(weightKg, age float64, male bool) float64 {
+	if heartRates := g.heartRates(); len(heartRates) > 0 {
+		var sum int
+
+		for _, hr := range heartRates {
+			sum += hr
+		}
+
+		hr := float64(sum) / float64(len(heartRates))
+
+		var perMinute float64
+
+		if male {
+			perMinute = (-55.0969 + 0.6309*hr + 0.1988*weightKg + 0.2017*age) / 4.184
+		} else {
+			perMinute = (-20.4022 + 0.4472*hr - 0.1263*weightKg + 0.074*age) / 4.184
+		}
+
+		return math.Max(0, perMinute*g.Duration()/60)
+	}
+
+	movingTime := g.MovingTime()
+
+	if movingTime == 0 {
+		return 0
+	}
+
+	// speed in m/min.
+	speed := g.MovingDistance() * 1000 / (movingTime / 60)
+	vo2 := 3.5 + 0.1*speed
+
+	if speed > 8000.0/60 {
+		vo2 = 3.5 + 0.2*speed
+	}
+
+	return vo2 / 3.5 * weightKg * movingTime / 3600
+}
+
+// SpeedTo returns the speed in m/s from w to w2, 0 when a timestamp can't be
+// parsed or the time delta isn't positive.
+func (w *WayPoint) SpeedTo(w2 *WayPoint) float64 {
+	start := w.Time()
+	end := w2.Time()
+
+	if start.IsZero() || end.IsZero() || !end.After(start) {
+		return 0
+	}
+
+	return w.Distance(w2) * 1000 / end.Sub(start).Seconds()
+}
+
+// trackPointExtension returns the TrackPointExtension of the point or nil.
+func (w *WayPoint) trackPointExtension() *TrackPointExtension {
+	if w.Extensions == nil {
+		return nil
+	}
+
+	return w.Extensions.TrackPointExtensions
+}
+
+// AverageCadence returns the average cadence of the track points with a
+// cadence reading, 0 when there is none.
+func (g *GPX) AverageCadence() int {
+	var sum, count int
+
+	for _, point := range g.Points() {
+		if extension := point.trackPointExtension(); extension != nil && extension.Cadence > 0 {
+			sum += extension.Cadence
+			count++
+		}
+	}
+
+	if count == 0 {
+		return 0
+	}
+
+	return int(math.Round(float64(sum) / float64(count)))
+}
+
+// AverageTemperature returns the average air temperature (atemp) of the track
+// points with a temperature reading, 0 when there is none. A reading of 0
+// can't be told apart from a missing one, so it is skipped.
+func (g *GPX) AverageTemperature() float64 {
+	temperatures := g.temperatures()
+
+	if len(temperatures) == 0 {
+		return 0
+	}
+
+	var sum float64
+
+	for _, temperature := range temperatures {
+		sum += temperature
+	}
+
+	return sum / float64(len(temperatures))
+}
+
+// MinAndMaxTemperature returns min and max air temperature of the track
+// points with a temperature reading. The ok value is false when there is none.
+func (g *GPX) MinAndMaxTemperature() (min, max float64, ok bool) {
+	temperatures := g.temperatures()
+
+	if len(temperatures) == 0 {
+		return 0, 0, false
+	}
+
+	min = temperatures[0]
+	max = temperatures[0]
+
+	for _, temperature := range temperatures {
+		min = math.Min(min, temperature)
+		max = math.Max(max, temperature)
+	}
+
+	return min, max, true
+}
+
+// temperatures returns the air temperature readings of every track point,
+// points without a reading are skipped.
+func (g *GPX) temperatures() []float64 {
+	var temperatures []float64
+
+	for _, point := range g.Points() {
+		if extension := point.trackPointExtension(); extension != nil && extension.Temperature != 0 {
+			temperatures = append(temperatures, extension.Temperature)
+		}
+	}
+
+	return temperatures
+}
+
+// CumulativeDistances returns the distance in kilometers from the start to
+// every track point, in the order of Points. The distance is accumulated like
+// Distance, without connecting the segments, so the last value equals Distance.
+func (g *GPX) CumulativeDistances() []float64 {
+	distances := []float64{}
+
+	var distance float64
+
+	for _, track := range g.Tracks {
+		for _, segment := range track.TrackSegments {
+			trackPoints := segment.TrackPoint
+
+			for i := range trackPoints {
+				if i > 0 {
+					distance += trackPoints[i-1].Distance(&trackPoints[i])
+				}
+
+				distances = append(distances, distance)
+			}
+		}
+	}
+
+	return distances
+}
+
+// SegmentDistances returns the distance in meters of every track point from
+// the previous point of its segment, so the first point of a segment has a
+// distance of 0. The distances sum up to Distance() * 1000.
+func (g *GPX) SegmentDistances() []float64 {
+	distances := []float64{}
+
+	for _, track := range g.Tracks {
+		for _, segment := range track.TrackSegments {
+			trackPoints := segment.TrackPoint
+
+			for i := range trackPoints {
+				var distance float64
+
+				if i > 0 {
+					distance = trackPoints[i-1].Distance(&trackPoints[i]) * 1000
+				}
+
+				distances = append(distances, distance)
+			}
+		}
+	}
+
+	return distances
+}
+
+// ProfilePoint is a point of an elevation profile.
+type ProfilePoint struct {
+	Distance  float64 // kilometers from the start
+	Elevation float64 // meters
+}
+
+// ElevationProfile returns the distance from the start paired with the
+// elevation of every track point. The distance is accumulated like Distance,
+// without connecting the segments. A point without elevation (see
+// HasElevation) gets the last known elevation, or the first known one before
+// any is known.
+func (g *GPX) ElevationProfile() []ProfilePoint {
+	return elevationProfile(g.Points(), g.CumulativeDistances())
+}
+
+// elevationProfile returns the elevation profile of the points at the
+// cumulative distances.
+func elevationProfile(points []WayPoint, distances []float64) []ProfilePoint {
+	profile := make([]ProfilePoint, len(distances))
+	first := -1
+
+	var lastElevation float64
+
+	for i, point := range points {
+		if point.HasElevation() {
+			lastElevation = point.Elevation
+
+			if first == -1 {
+				first = i
+			}
+		}
+
+		profile[i] = ProfilePoint{Distance: distances[i], Elevation: lastElevation}
+	}
+
+	for j := 0; j < first; j++ {
+		profile[j].Elevation = profile[first].Elevation
+	}
+
+	return profile
+}
+
+// Grades returns the grade in percent between every two consecutive track
+// points of a segment, the elevation change over the horizontal distance.
+// A positive grade is uphill and a negative one downhill. Point pairs without
+// horizontal distance, or with a point without elevation (see HasElevation),
+// have a grade of 0.
+func (g *GPX) Grades() []float64 {
+	grades := []float64{}
+
+	for _, track := range g.Tracks {
+		for _, segment := range track.TrackSegments {
+			trackPoints := segment.TrackPoint
+
+			for i := 1; i < len(trackPoints); i++ {
+				run := trackPoints[i-1].Distance(&trackPoints[i]) * 1000
+
+				if run == 0 {
+					grades = append(grades, 0)
+					continue
+				}
+
+				if !trackPoints[i-1].HasElevation() || !trackPoints[i].HasElevation() {
+					grades = append(grades, 0)
+					continue
+				}
+
+				rise := trackPoints[i].Elevation - trackPoints[i-1].Elevation
+				grades = append(grades, rise/run*100)
+			}
+		}
+	}
+
+	return grades
+}
+
+// GradeAdjustedPace returns the flat equivalent pace per kilometer. The
+// distance between every two consecutive track points is weighted by the
+// energy cost of running at its grade relative to flat ground, from the
+// Minetti et al. (2002) cost of running curve
+//
+//	C(i) = 155.4i^5 - 30.4i^4 - 43.3i^3 + 46.3i^2 + 19.5i + 3.6
+//
+// in J/kg/m with i the grade as a fraction, clamped to the measured range
+// of -0.45 to 0.45. The pace is the Duration over the weighted distance, so
+// it equals PaceInKM on flat ground. A zero Pace is returned when the
+// distance or the duration is 0.
+func (g *GPX) GradeAdjustedPace() *Pace {
+	var distance float64
+
+	grades := g.Grades()
+	k := 0
+
+	for _, track := range g.Tracks {
+		for _, segment := range track.TrackSegments {
+			trackPoints := segment.TrackPoint
+
+			for i := 1; i < len(trackPoints); i++ {
+				grade := math.Max(-0.45, math.Min(0.45, grades[k]/100))
+				distance += trackPoints[i-1].Distance(&trackPoints[i]) * runningCost(grade) / runningCost(0)
+				k++
+			}
+		}
+	}
+
+	return pace(g.Duration(), distance)
+}
+
+// runningCost returns the energy cost of running in J/kg/m at the grade,
+// ref: https://doi.org/10.1152/japplphysiol.01177.2001
+func runningCost(grade float64) float64 {
+	return 155.4*math.Pow(grade, 5) - 30.4*math.Pow(grade, 4) - 43.3*math.Pow(grade, 3) +
+		46.3*grade*grade + 19.5*grade + 3.6
+}
+
+// Stats is the summary of the track statistics.
+type Stats struct {
+	TotalDistance float64 `json:"totalDistance"` // kilometers
+	Duration      float64 `json:"duration"`      // seconds
+	MovingTime    float64 `json:"movingTime"`    // seconds
+	AverageSpeed  float64 `json:"averageSpeed"`  // km/h
+	MaxSpeed      float64 `json:"maxSpeed"`      // km/h
+	ElevationGain float64 `json:"elevationGain"` // meters
+	ElevationLoss float64 `json:"elevationLoss"` // meters
+	MinElevation  float64 `json:"minElevation"`  // meters
+	MaxElevation  float64 `json:"maxElevation"`  // meters
+	PointCount    int     `json:"pointCount"`
+}
+
+// Stats returns the summary of the track statistics, computed in a single
+// pass over the track points. Every value equals the one of its own method.
+func (g *GPX) Stats() Stats {
+	return g.StatsOptions(MovingOptions{})
+}
+
+// StatsOptions is like Stats with the given options.
+func (g *GPX) StatsOptions(opts MovingOptions) Stats {
+	opts = opts.withDefaults()
+
+	var stats Stats
+	var first, last *WayPoint
+	var hasElevation bool
+
+	for i := range g.Tracks {
+		for j := range g.Tracks[i].TrackSegments {
+			trackPoints := g.Tracks[i].TrackSegments[j].TrackPoint
+
+			// elevated is the last point of the segment with elevation.
+			var elevated *WayPoint
+
+			for k := range trackPoints {
+				point := &trackPoints[k]
+
+				if first == nil {
+					first = point
+				}
+
+				last = point
+				stats.PointCount++
+
+				if point.HasElevation() {
+					if !hasElevation {
+						stats.MinElevation = point.Elevation
+						stats.MaxElevation = point.Elevation
+						hasElevation = true
+					}
+
+					stats.MinElevation = math.Min(stats.MinElevation, point.Elevation)
+					stats.MaxElevation = math.Max(stats.MaxElevation, point.Elevation)
+
+					if elevated != nil {
+						if delta := point.Elevation - elevated.Elevation; delta > 0 {
+							stats.ElevationGain += delta
+						} else {
+							stats.ElevationLoss -= delta
+						}
+					}
+
+					elevated = point
+				}
+
+				if k == 0 {
+					continue
+				}
+
+				previous := &trackPoints[k-1]
+				distance := previous.Distance(point)
+				stats.TotalDistance += distance
+
+				start, end := previous.Time(), point.Time()
+
+				if start.IsZero() || end.IsZero() || !end.After(start) {
+					continue
+				}
+
+				gap := end.Sub(start)
+				speed := distance * 1000 / gap.Seconds()
+
+				stats.MaxSpeed = math.Max(stats.MaxSpeed, speed*3.6)
+
+				if opts.isMoving(speed, gap) {
+					stats.MovingTime += gap.Seconds()
+				}
+			}
+		}
+	}
+
+	if first != nil && first != last {
+		if start, end := first.Time(), last.Time(); end.After(start) {
+			stats.Duration = end.Sub(start).Seconds()
+			stats.AverageSpeed = stats.TotalDistance / (stats.Duration / 3600)
+		}
+	}
+
+	return stats
+}
@@ -0,0 +1,672 @@
+package gpx
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElevationGainAndLoss(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	assert.InDelta(t, 1.4, gpx.ElevationGain(), 1e-9)
+	assert.InDelta(t, 0.8, gpx.ElevationLoss(), 1e-9)
+}
+
+func TestElevationGainAndLossFeet(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	assert.InDelta(t, 4.593, gpx.ElevationGainFeet(), 0.001)
+	assert.InDelta(t, 2.625, gpx.ElevationLossFeet(), 0.001)
+}
+
+func TestElevationGainAndLossTwoSegments(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, 2.0, gpx.ElevationGain())
+	assert.Equal(t, 2.0, gpx.ElevationLoss())
+}
+
+func TestElevationGainAndLossGap(t *testing.T) {
+	b := openGPX("_data/elevation-gap.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, 10.0, gpx.ElevationGain())
+	assert.Equal(t, 0.0, gpx.ElevationLoss())
+
+	gain, loss := gpx.ElevationChange(20)
+
+	assert.Equal(t, 0.0, gain)
+	assert.Equal(t, 0.0, loss)
+
+	// 400 m/h: 10 m over 90 s of moving time.
+	assert.InDelta(t, 400.0, gpx.VAM(), 1e-9)
+}
+
+func TestElevationChangeThreshold(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	gain, loss := gpx.ElevationChange(0.5)
+
+	assert.InDelta(t, 0.8, gain, 1e-9)
+	assert.InDelta(t, 0.6, loss, 1e-9)
+
+	gain, loss = gpx.ElevationChange(1)
+
+	assert.Equal(t, 0.0, gain)
+	assert.Equal(t, 0.0, loss)
+}
+
+func TestElevationGainEmpty(t *testing.T) {
+	gpx := &GPX{}
+
+	assert.Equal(t, 0.0, gpx.ElevationGain())
+	assert.Equal(t, 0.0, gpx.ElevationLoss())
+}
+
+func TestAverageSpeed(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	assert.InDelta(t, gpx.Distance()/34*3600, gpx.AverageSpeed(), 1e-9)
+	assert.Equal(t, 0.0, (&GPX{}).AverageSpeed())
+
+	b = openGPX("_data/zero-duration.gpx")
+	gpx, _ = ReadGPX(b)
+
+	assert.Equal(t, 0.0, gpx.AverageSpeed())
+}
+
+func TestMaxSpeed(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	// 0.001 degree of latitude every 30 seconds.
+	assert.InDelta(t, 13.343, gpx.MaxSpeed(), 0.001)
+	assert.Equal(t, 0.0, (&GPX{}).MaxSpeed())
+
+	b = openGPX("_data/zero-duration.gpx")
+	gpx, _ = ReadGPX(b)
+
+	assert.Equal(t, 0.0, gpx.MaxSpeed())
+}
+
+func TestSpeeds(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	speeds := gpx.Speeds()
+	points := gpx.Points()
+
+	assert.Len(t, speeds, len(points)-len(gpx.Tracks[0].TrackSegments))
+	assert.InDelta(t, points[0].Distance(&points[1])*1000/30, speeds[0], 1e-9)
+	assert.InDelta(t, points[4].Distance(&points[5])*1000/30, speeds[3], 1e-9)
+	assert.Empty(t, (&GPX{}).Speeds())
+}
+
+func TestSpeedsWithoutTimeDelta(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5, Timestamp: "2020-05-03T07:00:00Z"},
+		{Latitude: 25.001, Longitude: 121.5, Timestamp: "2020-05-03T07:00:00Z"},
+		{Latitude: 25.002, Longitude: 121.5},
+	})
+
+	assert.Equal(t, []float64{0, 0}, gpx.Speeds())
+}
+
+func TestStops(t *testing.T) {
+	b := openGPX("_data/pause.gpx")
+	gpx, _ := ReadGPX(b)
+	stops := gpx.Stops(2*time.Minute, 10)
+
+	assert.Len(t, stops, 1)
+	assert.InDelta(t, 25.005005, stops[0].Center.Latitude, 1e-6)
+	assert.InDelta(t, 121.500005, stops[0].Center.Longitude, 1e-6)
+	assert.Equal(t, time.Date(2020, 5, 3, 7, 2, 30, 0, time.UTC), stops[0].Start)
+	assert.Equal(t, 5*time.Minute, stops[0].Duration)
+	assert.Empty(t, gpx.Stops(10*time.Minute, 10))
+	assert.Empty(t, gpx.Stops(2*time.Minute, 1))
+
+	b = openGPX("_data/loop.gpx")
+	gpx, _ = ReadGPX(b)
+
+	assert.Empty(t, gpx.Stops(time.Minute, 10))
+}
+
+func TestStopsOptions(t *testing.T) {
+	b := openGPX("_data/pause.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, gpx.Stops(2*time.Minute, 10), gpx.StopsOptions(2*time.Minute, 10, MovingOptions{}))
+	assert.Len(t, gpx.StopsOptions(2*time.Minute, 10, MovingOptions{StoppedSpeed: 0.1}), 1)
+
+	// The GPS jitter of about 0.03 m/s during the pause counts as moving.
+	assert.Empty(t, gpx.StopsOptions(2*time.Minute, 10, MovingOptions{StoppedSpeed: 0.01}))
+	assert.Empty(t, gpx.StopsOptions(2*time.Minute, 10, MovingOptions{StoppedSpeed: AnySpeed}))
+}
+
+func TestTimeGaps(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Timestamp: "2020-05-03T07:00:00Z"},
+		{Timestamp: "2020-05-03T07:00:30Z"},
+		{Timestamp: "2020-05-03T07:00:30Z"},
+		{Timestamp: "2020-05-03T07:00:20Z"},
+		{},
+		{Timestamp: "2020-05-03T07:05:00Z"},
+	}, []WayPoint{
+		{Timestamp: "2020-05-03T07:10:00Z"},
+		{Timestamp: "2020-05-03T07:10:01.5Z"},
+	})
+
+	assert.Equal(t, []time.Duration{30 * time.Second, 0, 0, 0, 0, 1500 * time.Millisecond}, gpx.TimeGaps())
+	assert.Len(t, gpx.TimeGaps(), len(gpx.Speeds()))
+	assert.Empty(t, (&GPX{}).TimeGaps())
+}
+
+func TestSpeedBuckets(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5, Timestamp: "2020-05-03T07:00:00Z"},
+		{Latitude: 25.0001, Longitude: 121.5, Timestamp: "2020-05-03T07:00:10Z"},
+		{Latitude: 25.0002, Longitude: 121.5, Timestamp: "2020-05-03T07:00:20Z"},
+		{Latitude: 25.0012, Longitude: 121.5, Timestamp: "2020-05-03T07:00:50Z"},
+		{Latitude: 25.0022, Longitude: 121.5, Timestamp: "2020-05-03T07:01:20Z"},
+		{Latitude: 25.0023, Longitude: 121.5, Timestamp: "2020-05-03T07:01:30Z"},
+	}, []WayPoint{
+		{Latitude: 25.010, Longitude: 121.5, Timestamp: "2020-05-03T07:05:00Z"},
+		{Latitude: 25.0101, Longitude: 121.5, Timestamp: "2020-05-03T07:05:10Z"},
+	})
+	points := gpx.Points()
+
+	// About 1.1 m/s walking and 3.7 m/s running.
+	buckets := gpx.SpeedBuckets([]float64{2, 5})
+
+	assert.Len(t, buckets, 4)
+	assert.Equal(t, points[0:3], buckets[0])
+	assert.Equal(t, points[2:5], buckets[1])
+	assert.Equal(t, points[4:6], buckets[2])
+	assert.Equal(t, points[6:8], buckets[3])
+	assert.Len(t, gpx.SpeedBuckets(nil), 2)
+	assert.Empty(t, (&GPX{}).SpeedBuckets([]float64{2}))
+}
+
+func TestSpeedBucketsAtThreshold(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5, Timestamp: "2020-05-03T07:00:00Z"},
+		{Latitude: 25.001, Longitude: 121.5, Timestamp: "2020-05-03T07:00:30Z"},
+		{Latitude: 25.002, Longitude: 121.5, Timestamp: "2020-05-03T07:00:30Z"},
+	})
+	speed := gpx.Speeds()[0]
+
+	assert.Len(t, gpx.SpeedBuckets([]float64{speed}), 2)
+	assert.Len(t, gpx.SpeedBuckets([]float64{speed + 1}), 1)
+}
+
+func TestMovingTime(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, 34.0, gpx.MovingTime())
+	assert.Equal(t, 0.0, gpx.StoppedTime())
+}
+
+func TestMovingTimeTwoSegments(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, 120.0, gpx.MovingTime())
+	assert.Equal(t, 240.0, gpx.StoppedTime())
+}
+
+func TestMovingTimeWithStop(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5, Timestamp: "2020-05-03T07:00:00Z"},
+		{Latitude: 25.001, Longitude: 121.5, Timestamp: "2020-05-03T07:00:30Z"},
+		{Latitude: 25.001, Longitude: 121.5, Timestamp: "2020-05-03T07:00:50Z"},
+		{Latitude: 25.002, Longitude: 121.5, Timestamp: "2020-05-03T07:01:20Z"},
+		{Latitude: 25.003, Longitude: 121.5, Timestamp: "2020-05-03T07:05:00Z"},
+	})
+
+	assert.Equal(t, 60.0, gpx.MovingTime())
+	assert.Equal(t, 240.0, gpx.StoppedTime())
+}
+
+func TestMovingDistance(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5, Timestamp: "2020-05-03T07:00:00Z"},
+		{Latitude: 25.001, Longitude: 121.5, Timestamp: "2020-05-03T07:00:30Z"},
+		{Latitude: 25.00101, Longitude: 121.5, Timestamp: "2020-05-03T07:01:30Z"},
+		{Latitude: 25.00201, Longitude: 121.5, Timestamp: "2020-05-03T07:02:00Z"},
+	})
+	points := gpx.Points()
+	jitter := points[1].Distance(&points[2])
+
+	assert.InDelta(t, gpx.Distance()-jitter, gpx.MovingDistance(), 1e-12)
+	assert.Less(t, gpx.MovingDistance(), gpx.Distance())
+	assert.Equal(t, 0.0, (&GPX{}).MovingDistance())
+}
+
+func TestMovingTimeThreshold(t *testing.T) {
+	defer func(threshold float64) { StoppedSpeedThreshold = threshold }(StoppedSpeedThreshold)
+
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	StoppedSpeedThreshold = 5
+
+	assert.Equal(t, 0.0, gpx.MovingTime())
+	assert.Equal(t, 360.0, gpx.StoppedTime())
+}
+
+func TestMovingOptions(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5, Timestamp: "2020-05-03T07:00:00Z"},
+		{Latitude: 25.001, Longitude: 121.5, Timestamp: "2020-05-03T07:00:30Z"},
+		{Latitude: 25.001, Longitude: 121.5, Timestamp: "2020-05-03T07:00:50Z"},
+		{Latitude: 25.002, Longitude: 121.5, Timestamp: "2020-05-03T07:01:20Z"},
+		{Latitude: 25.003, Longitude: 121.5, Timestamp: "2020-05-03T07:05:00Z"},
+	})
+
+	assert.Equal(t, gpx.MovingTime(), gpx.MovingTimeOptions(MovingOptions{}))
+	assert.Equal(t, gpx.Stats(), gpx.StatsOptions(MovingOptions{}))
+
+	opts := MovingOptions{StoppedSpeed: 0.4, MaxGap: 5 * time.Minute}
+
+	assert.Equal(t, 280.0, gpx.MovingTimeOptions(opts))
+	assert.Equal(t, 20.0, gpx.StoppedTimeOptions(opts))
+	assert.InDelta(t, gpx.Distance(), gpx.MovingDistanceOptions(opts), 1e-12)
+	assert.Equal(t, 280.0, gpx.StatsOptions(opts).MovingTime)
+
+	opts = MovingOptions{StoppedSpeed: AnySpeed, MaxGap: 5 * time.Minute}
+
+	assert.Equal(t, 300.0, gpx.MovingTimeOptions(opts))
+	assert.Equal(t, 300.0, gpx.StatsOptions(opts).MovingTime)
+
+	opts = MovingOptions{StoppedSpeed: 5}
+
+	assert.Equal(t, 0.0, gpx.MovingTimeOptions(opts))
+	assert.Equal(t, 300.0, gpx.StoppedTimeOptions(opts))
+	assert.Equal(t, 0.0, gpx.MovingDistanceOptions(opts))
+	assert.Equal(t, 0.0, gpx.StatsOptions(opts).MovingTime)
+}
+
+func TestMovingTimeEmpty(t *testing.T) {
+	assert.Equal(t, 0.0, (&GPX{}).MovingTime())
+	assert.Equal(t, 0.0, (&GPX{}).StoppedTime())
+}
+
+func TestVAM(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, 60.0, gpx.VAM())
+}
+
+func TestVAMWithoutAscentOrMovingTime(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5, Elevation: 10, Timestamp: "2020-05-03T07:00:00Z"},
+		{Latitude: 25.001, Longitude: 121.5, Elevation: 5, Timestamp: "2020-05-03T07:00:30Z"},
+	})
+
+	assert.Equal(t, 0.0, gpx.VAM())
+	assert.Equal(t, 0.0, (&GPX{}).VAM())
+}
+
+func TestSplits(t *testing.T) {
+	var points []WayPoint
+
+	start := time.Date(2020, 5, 3, 7, 0, 0, 0, time.UTC)
+
+	// 0.001 degree of latitude (about 111m) every 30 seconds.
+	for i := 0; i <= 20; i++ {
+		points = append(points, WayPoint{
+			Latitude:  25 + float64(i)*0.001,
+			Longitude: 121.5,
+			Timestamp: start.Add(time.Duration(i) * 30 * time.Second).Format(time.RFC3339),
+		})
+	}
+
+	gpx := newTestGPX(points)
+	splits := gpx.Splits(1)
+
+	assert.Len(t, splits, 3)
+
+	step := points[0].Distance(&points[1])
+	secondsPerKm := 30 / step
+
+	assert.Equal(t, 1.0, splits[0].Distance)
+	assert.InDelta(t, secondsPerKm, splits[0].Duration, 1e-3)
+	assert.Equal(t, Pace{int(math.Round(secondsPerKm)) / 60, int(math.Round(secondsPerKm)) % 60}, splits[0].Pace)
+	assert.InDelta(t, secondsPerKm, splits[1].Duration, 1e-3)
+
+	assert.InDelta(t, gpx.Distance()-2, splits[2].Distance, 1e-9)
+	assert.InDelta(t, 600, splits[0].Duration+splits[1].Duration+splits[2].Duration, 1e-3)
+}
+
+func TestSplitsExactDistance(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	splits := gpx.Splits(gpx.Tracks[0].TrackSegments[0].TrackPoint[0].Distance(&gpx.Tracks[0].TrackSegments[0].TrackPoint[2]))
+
+	assert.Len(t, splits, 2)
+	assert.InDelta(t, 60.0, splits[0].Duration, 1e-3)
+	assert.InDelta(t, 300.0, splits[1].Duration, 1e-3)
+}
+
+func TestSplitsEmpty(t *testing.T) {
+	assert.Empty(t, (&GPX{}).Splits(1))
+	assert.Empty(t, newTestGPX([]WayPoint{{Timestamp: "2020-05-03T07:00:00Z"}}).Splits(1))
+
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	assert.Empty(t, gpx.Splits(0))
+}
+
+func TestDetectLaps(t *testing.T) {
+	b := openGPX("_data/loop.gpx")
+	gpx, _ := ReadGPX(b)
+
+	laps := gpx.DetectLaps(50)
+
+	assert.Len(t, laps, 3)
+
+	for _, lap := range laps {
+		assert.InDelta(t, gpx.Distance()/3, lap.Distance, 1e-9)
+		assert.Equal(t, 240.0, lap.Duration)
+	}
+}
+
+func TestDetectLapsWithoutReturn(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	laps := gpx.DetectLaps(50)
+
+	assert.Len(t, laps, 1)
+	assert.InDelta(t, gpx.Distance(), laps[0].Distance, 1e-12)
+	assert.Equal(t, 360.0, laps[0].Duration)
+	assert.Len(t, (&GPX{}).DetectLaps(50), 0)
+}
+
+func TestHeartRate(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, 123, gpx.AverageHeartRate())
+	assert.Equal(t, 140, gpx.MaxHeartRate())
+	assert.Equal(t, 104, gpx.MinHeartRate())
+}
+
+func TestHeartRateSkipsMissingReadings(t *testing.T) {
+	b := openGPX("_data/garmin-tpx.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, 112, gpx.AverageHeartRate())
+	assert.Equal(t, 125, gpx.MaxHeartRate())
+	assert.Equal(t, 98, gpx.MinHeartRate())
+}
+
+func TestHeartRateEmpty(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, 0, gpx.AverageHeartRate())
+	assert.Equal(t, 0, gpx.MaxHeartRate())
+	assert.Equal(t, 0, gpx.MinHeartRate())
+}
+
+func TestEstimateCaloriesFromHeartRate(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	assert.InDelta(t, 5.74, gpx.EstimateCalories(70, 30, true), 0.01)
+	assert.InDelta(t, 3.95, gpx.EstimateCalories(60, 30, false), 0.01)
+}
+
+func TestEstimateCaloriesFromSpeed(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.InDelta(t, 31.99, gpx.EstimateCalories(70, 30, true), 0.01)
+	assert.Equal(t, 0.0, (&GPX{}).EstimateCalories(70, 30, true))
+}
+
+func TestEstimateCaloriesFromMovingSpeed(t *testing.T) {
+	b := openGPX("_data/pause.gpx")
+	gpx, _ := ReadGPX(b)
+
+	movingTime := gpx.MovingTime()
+	speed := gpx.MovingDistance() * 1000 / (movingTime / 60)
+
+	assert.Less(t, gpx.MovingDistance(), gpx.Distance())
+	assert.Greater(t, speed, 8000.0/60)
+	assert.InDelta(t, (3.5+0.2*speed)/3.5*70*movingTime/3600, gpx.EstimateCalories(70, 30, true), 1e-9)
+}
+
+func TestAverageCadence(t *testing.T) {
+	b := openGPX("_data/garmin-tpx.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, 83, gpx.AverageCadence())
+	assert.Equal(t, 0, (&GPX{}).AverageCadence())
+}
+
+func TestTemperature(t *testing.T) {
+	b := openGPX("_data/garmin-tpx.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.InDelta(t, 23.667, gpx.AverageTemperature(), 0.001)
+
+	min, max, ok := gpx.MinAndMaxTemperature()
+
+	assert.True(t, ok)
+	assert.Equal(t, 23.0, min)
+	assert.Equal(t, 24.0, max)
+}
+
+func TestTemperatureEmpty(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, 0.0, gpx.AverageTemperature())
+
+	_, _, ok := gpx.MinAndMaxTemperature()
+
+	assert.False(t, ok)
+}
+
+func TestElevationProfile(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	profile := gpx.ElevationProfile()
+
+	assert.Len(t, profile, 6)
+	assert.Equal(t, ProfilePoint{Distance: 0, Elevation: 10}, profile[0])
+	assert.Equal(t, 12.0, profile[2].Elevation)
+	assert.Equal(t, profile[2].Distance, profile[3].Distance)
+	assert.InDelta(t, gpx.Distance(), profile[5].Distance, 1e-12)
+}
+
+func TestElevationProfileMissingElevation(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5},
+		{Latitude: 25.001, Longitude: 121.5, Elevation: 20},
+		{Latitude: 25.002, Longitude: 121.5},
+		{Latitude: 25.003, Longitude: 121.5, Elevation: 30},
+	})
+
+	profile := gpx.ElevationProfile()
+
+	assert.Equal(t, 20.0, profile[0].Elevation)
+	assert.Equal(t, 20.0, profile[1].Elevation)
+	assert.Equal(t, 20.0, profile[2].Elevation)
+	assert.Equal(t, 30.0, profile[3].Elevation)
+}
+
+func TestElevationProfileEmpty(t *testing.T) {
+	assert.Empty(t, (&GPX{}).ElevationProfile())
+}
+
+func TestCumulativeDistances(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	distances := gpx.CumulativeDistances()
+
+	assert.Len(t, distances, 6)
+	assert.Equal(t, 0.0, distances[0])
+	assert.Equal(t, distances[2], distances[3])
+	assert.InDelta(t, gpx.Distance(), distances[5], 1e-12)
+
+	for i := 1; i < len(distances); i++ {
+		assert.True(t, distances[i] >= distances[i-1])
+	}
+
+	b = openGPX(testGPX)
+	gpx, _ = ReadGPX(b)
+	distances = gpx.CumulativeDistances()
+
+	assert.Equal(t, gpx.Distance(), distances[len(distances)-1])
+	assert.Empty(t, (&GPX{}).CumulativeDistances())
+}
+
+func TestSegmentDistances(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	distances := gpx.SegmentDistances()
+	points := gpx.Points()
+
+	assert.Len(t, distances, 6)
+	assert.Equal(t, 0.0, distances[0])
+	assert.Equal(t, 0.0, distances[3])
+	assert.Equal(t, points[0].Distance(&points[1])*1000, distances[1])
+
+	var sum float64
+
+	for _, distance := range distances {
+		sum += distance
+	}
+
+	assert.InDelta(t, gpx.Distance()*1000, sum, 1e-9)
+	assert.Empty(t, (&GPX{}).SegmentDistances())
+}
+
+func TestGradesGap(t *testing.T) {
+	b := openGPX("_data/elevation-gap.gpx")
+	gpx, _ := ReadGPX(b)
+
+	points := gpx.Points()
+	grades := gpx.Grades()
+
+	assert.Len(t, grades, 3)
+	assert.Equal(t, 0.0, grades[0])
+	assert.Equal(t, 0.0, grades[1])
+	assert.InDelta(t, 10/(points[2].Distance(&points[3])*1000)*100, grades[2], 1e-9)
+}
+
+func TestGrades(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	grades := gpx.Grades()
+	run := gpx.Points()[0].Distance(&gpx.Points()[1]) * 1000
+
+	assert.Len(t, grades, 4)
+	assert.InDelta(t, 100/run, grades[0], 1e-9)
+	assert.InDelta(t, -100/run, grades[3], 1e-9)
+	assert.Greater(t, grades[1], 0.0)
+	assert.Less(t, grades[2], 0.0)
+}
+
+func TestGradesWithoutHorizontalDistance(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25, Longitude: 121.5, Elevation: 10},
+		{Latitude: 25, Longitude: 121.5, Elevation: 20},
+	})
+
+	assert.Equal(t, []float64{0}, gpx.Grades())
+	assert.Empty(t, (&GPX{}).Grades())
+}
+
+func TestSpeedTo(t *testing.T) {
+	start := WayPoint{Latitude: 25.000, Longitude: 121.5, Timestamp: "2020-05-03T07:00:00Z"}
+	end := WayPoint{Latitude: 25.001, Longitude: 121.5, Timestamp: "2020-05-03T07:00:30Z"}
+
+	assert.InDelta(t, start.Distance(&end)*1000/30, start.SpeedTo(&end), 1e-9)
+	assert.InDelta(t, 3.706, start.SpeedTo(&end), 0.001)
+}
+
+func TestSpeedToWithoutTimeDelta(t *testing.T) {
+	start := WayPoint{Latitude: 25.000, Longitude: 121.5, Timestamp: "2020-05-03T07:00:30Z"}
+	same := WayPoint{Latitude: 25.001, Longitude: 121.5, Timestamp: "2020-05-03T07:00:30Z"}
+	before := WayPoint{Latitude: 25.001, Longitude: 121.5, Timestamp: "2020-05-03T07:00:00Z"}
+	invalid := WayPoint{Latitude: 25.001, Longitude: 121.5, Timestamp: "invalid"}
+
+	assert.Equal(t, 0.0, start.SpeedTo(&same))
+	assert.Equal(t, 0.0, start.SpeedTo(&before))
+	assert.Equal(t, 0.0, start.SpeedTo(&invalid))
+	assert.Equal(t, 0.0, invalid.SpeedTo(&start))
+}
+
+func TestGradeAdjustedPace(t *testing.T) {
+	flat := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5, Elevation: 10, Timestamp: "2020-05-03T07:00:00Z"},
+		{Latitude: 25.001, Longitude: 121.5, Elevation: 10, Timestamp: "2020-05-03T07:01:00Z"},
+	})
+	uphill := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5, Elevation: 10, Timestamp: "2020-05-03T07:00:00Z"},
+		{Latitude: 25.001, Longitude: 121.5, Elevation: 21.12, Timestamp: "2020-05-03T07:01:00Z"},
+	})
+
+	assert.Equal(t, flat.PaceInKM(), flat.GradeAdjustedPace())
+	assert.Equal(t, &Pace{9, 0}, uphill.PaceInKM())
+	assert.Equal(t, &Pace{5, 25}, uphill.GradeAdjustedPace())
+	assert.Equal(t, &Pace{}, (&GPX{}).GradeAdjustedPace())
+}
+
+func TestStats(t *testing.T) {
+	for _, path := range []string{testGPX, "_data/two-segments.gpx", "_data/garmin-tpx.gpx", "_data/zero-duration.gpx", "_data/elevation-gap.gpx", "_data/missing-elevation.gpx"} {
+		b := openGPX(path)
+		gpx, _ := ReadGPX(b)
+
+		stats := gpx.Stats()
+		min, max, _ := gpx.MinAndMaxElevation()
+
+		assert.InDelta(t, gpx.Distance(), stats.TotalDistance, 1e-12, path)
+		assert.Equal(t, gpx.Duration(), stats.Duration, path)
+		assert.Equal(t, gpx.MovingTime(), stats.MovingTime, path)
+		assert.InDelta(t, gpx.AverageSpeed(), stats.AverageSpeed, 1e-9, path)
+		assert.InDelta(t, gpx.MaxSpeed(), stats.MaxSpeed, 1e-9, path)
+		assert.InDelta(t, gpx.ElevationGain(), stats.ElevationGain, 1e-9, path)
+		assert.InDelta(t, gpx.ElevationLoss(), stats.ElevationLoss, 1e-9, path)
+		assert.Equal(t, min, stats.MinElevation, path)
+		assert.Equal(t, max, stats.MaxElevation, path)
+		assert.Equal(t, len(gpx.Points()), stats.PointCount, path)
+	}
+}
+
+func TestStatsJSON(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	output, err := json.Marshal(gpx.Stats())
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(output), `"duration":360`)
+	assert.Contains(t, string(output), `"movingTime":120`)
+	assert.Contains(t, string(output), `"pointCount":6`)
+}
+
+func TestStatsEmpty(t *testing.T) {
+	assert.Equal(t, Stats{}, (&GPX{}).Stats())
+}
@@ -0,0 +1,160 @@
+package gpx
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+
+	"golang.org/x/net/html/charset"
+)
+
+// StreamTrackPoints decodes the GPX from r and calls fn for every track
+// point as soon as it is parsed, without keeping the document in memory.
+// It stops and returns the error returned by fn, if any.
+func StreamTrackPoints(r io.Reader, fn func(WayPoint) error) error {
+	d := xml.NewDecoder(r)
+	d.CharsetReader = charset.NewReaderLabel
+
+	for {
+		token, err := d.Token()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		start, ok := token.(xml.StartElement)
+
+		if !ok || start.Name.Local != "trkpt" {
+			continue
+		}
+
+		var point WayPoint
+
+		if err := d.DecodeElement(&point, &start); err != nil {
+			return err
+		}
+
+		if err := fn(point); err != nil {
+			return err
+		}
+	}
+}
+
+// TrackWriter writes a GPX 1.1 document of a single track to an io.Writer
+// point by point, without keeping the track in memory:
+//
+//	tw := NewTrackWriter(w)
+//	for rows.Next() {
+//		if err := tw.WritePoint(point); err != nil { ... }
+//	}
+//	err := tw.Close()
+//
+// The document is only valid once Close is called. The first error stops
+// the writer and is returned by every later call.
+type TrackWriter struct {
+	e       *xml.Encoder
+	started bool
+	closed  bool
+	err     error
+}
+
+// NewTrackWriter returns a TrackWriter writing to w.
+func NewTrackWriter(w io.Writer) *TrackWriter {
+	return &TrackWriter{e: xml.NewEncoder(w)}
+}
+
+// WritePoint writes the track point in the current track segment.
+func (tw *TrackWriter) WritePoint(w WayPoint) error {
+	if !tw.start() {
+		return tw.err
+	}
+
+	tw.err = tw.e.EncodeElement(w, xml.StartElement{Name: xml.Name{Local: "trkpt"}})
+
+	return tw.err
+}
+
+// NewSegment ends the current track segment and begins a new one, for a
+// pause in the recording.
+func (tw *TrackWriter) NewSegment() error {
+	if !tw.start() {
+		return tw.err
+	}
+
+	tw.encodeTokens(xml.EndElement{Name: xml.Name{Local: "trkseg"}}, xml.StartElement{Name: xml.Name{Local: "trkseg"}})
+
+	return tw.err
+}
+
+// Close ends the document and flushes it to the underlying writer, which is
+// not closed. A track without any point is written when nothing was.
+func (tw *TrackWriter) Close() error {
+	if !tw.start() {
+		return tw.err
+	}
+
+	tw.encodeTokens(
+		xml.EndElement{Name: xml.Name{Local: "trkseg"}},
+		xml.EndElement{Name: xml.Name{Local: "trk"}},
+		xml.EndElement{Name: xml.Name{Local: "gpx"}},
+	)
+
+	if tw.err == nil {
+		tw.err = tw.e.Flush()
+	}
+
+	tw.closed = true
+
+	return tw.err
+}
+
+// start begins the document on first use, and reports whether the writer
+// can go on.
+func (tw *TrackWriter) start() bool {
+	if tw.err != nil {
+		return false
+	}
+
+	if tw.closed {
+		tw.err = errors.New("gpx: TrackWriter is closed")
+
+		return false
+	}
+
+	if tw.started {
+		return true
+	}
+
+	tw.started = true
+
+	root := rootStartElement(&GPX{})
+	root.Attr = append(root.Attr,
+		xml.Attr{Name: xml.Name{Local: "version"}, Value: "1.1"},
+		xml.Attr{Name: xml.Name{Local: "creator"}, Value: DefaultCreator},
+	)
+
+	tw.encodeTokens(
+		xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8"`)},
+		xml.CharData("\n"),
+		root,
+		xml.StartElement{Name: xml.Name{Local: "trk"}},
+		xml.StartElement{Name: xml.Name{Local: "trkseg"}},
+	)
+
+	return tw.err == nil
+}
+
+// encodeTokens encodes the tokens until the first error.
+func (tw *TrackWriter) encodeTokens(tokens ...xml.Token) {
+	for _, token := range tokens {
+		if tw.err != nil {
+			return
+		}
+
+		tw.err = tw.e.EncodeToken(token)
+	}
+}
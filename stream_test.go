@@ -0,0 +1,133 @@
+package gpx
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamTrackPoints(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	var points []WayPoint
+
+	b = openGPX("_data/two-segments.gpx")
+	err := StreamTrackPoints(b, func(w WayPoint) error {
+		points = append(points, w)
+
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, gpx.Points(), points)
+}
+
+func TestStreamTrackPointsSkipsOtherPoints(t *testing.T) {
+	count := 0
+
+	b := openGPX("_data/waypoints.gpx")
+	err := StreamTrackPoints(b, func(w WayPoint) error {
+		count++
+
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestStreamTrackPointsStopsOnError(t *testing.T) {
+	stop := errors.New("stop")
+	count := 0
+
+	b := openGPX(testGPX)
+	err := StreamTrackPoints(b, func(w WayPoint) error {
+		count++
+
+		if count == 3 {
+			return stop
+		}
+
+		return nil
+	})
+
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestStreamTrackPointsMalformed(t *testing.T) {
+	r := strings.NewReader(`<gpx><trk><trkseg><trkpt lat="25" lon="121"><ele>1</trkpt>`)
+	err := StreamTrackPoints(r, func(w WayPoint) error { return nil })
+
+	assert.Error(t, err)
+}
+
+func TestTrackWriter(t *testing.T) {
+	b := openGPX("_data/garmin-tpx.gpx")
+	gpx, _ := ReadGPX(b)
+	points := gpx.Points()
+
+	var buf strings.Builder
+
+	tw := NewTrackWriter(&buf)
+
+	assert.NoError(t, tw.WritePoint(points[0]))
+	assert.NoError(t, tw.WritePoint(points[1]))
+	assert.NoError(t, tw.NewSegment())
+	assert.NoError(t, tw.WritePoint(points[2]))
+	assert.NoError(t, tw.WritePoint(points[3]))
+	assert.NoError(t, tw.Close())
+
+	assert.True(t, strings.HasPrefix(buf.String(), xml.Header))
+
+	result, err := ReadGPX(strings.NewReader(buf.String()))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1", result.Version)
+	assert.Equal(t, DefaultCreator, result.Creator)
+	assert.Len(t, result.Tracks, 1)
+	assert.Len(t, result.Tracks[0].TrackSegments, 2)
+	assert.Equal(t, points, result.Points())
+	assert.Empty(t, result.Validate())
+}
+
+func TestTrackWriterEmpty(t *testing.T) {
+	var buf strings.Builder
+
+	assert.NoError(t, NewTrackWriter(&buf).Close())
+
+	result, err := ReadGPX(strings.NewReader(buf.String()))
+
+	assert.NoError(t, err)
+	assert.Len(t, result.Tracks, 1)
+	assert.Equal(t, 0, result.NumPoints())
+}
+
+func TestTrackWriterClosed(t *testing.T) {
+	var buf strings.Builder
+
+	tw := NewTrackWriter(&buf)
+
+	assert.NoError(t, tw.Close())
+	assert.Error(t, tw.WritePoint(WayPoint{}))
+	assert.Error(t, tw.Close())
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestTrackWriterError(t *testing.T) {
+	tw := NewTrackWriter(failingWriter{})
+
+	tw.WritePoint(WayPoint{Latitude: 25, Longitude: 121.5})
+
+	assert.EqualError(t, tw.Close(), "write failed")
+	assert.EqualError(t, tw.WritePoint(WayPoint{}), "write failed")
+}
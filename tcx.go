@@ -0,0 +1,181 @@
+package gpx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// TCXNamespace is the Garmin Training Center Database v2 XML namespace.
+const TCXNamespace = "http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2"
+
+// tcx is the representation of a TCX document.
+// ref: https://www8.garmin.com/xmlschemas/TrainingCenterDatabasev2.xsd
+type tcx struct {
+	XMLName    xml.Name      `xml:"TrainingCenterDatabase"`
+	XMLNS      string        `xml:"xmlns,attr"`
+	Activities []tcxActivity `xml:"Activities>Activity"`
+}
+
+// tcxActivity is the representation of a TCX Activity.
+type tcxActivity struct {
+	Sport string `xml:"Sport,attr"`
+	ID    string `xml:"Id"`
+	Lap   tcxLap `xml:"Lap"`
+}
+
+// tcxLap is the representation of a TCX Lap.
+type tcxLap struct {
+	StartTime        string     `xml:"StartTime,attr"`
+	TotalTimeSeconds float64    `xml:"TotalTimeSeconds"`
+	DistanceMeters   float64    `xml:"DistanceMeters"`
+	Calories         int        `xml:"Calories"`
+	Intensity        string     `xml:"Intensity"`
+	TriggerMethod    string     `xml:"TriggerMethod"`
+	Tracks           []tcxTrack `xml:"Track"`
+}
+
+// tcxTrack is the representation of a TCX Track.
+type tcxTrack struct {
+	Trackpoints []tcxTrackpoint `xml:"Trackpoint"`
+}
+
+// tcxTrackpoint is the representation of a TCX Trackpoint.
+type tcxTrackpoint struct {
+	Time           string        `xml:"Time"`
+	Position       tcxPosition   `xml:"Position"`
+	AltitudeMeters *float64      `xml:"AltitudeMeters,omitempty"`
+	DistanceMeters float64       `xml:"DistanceMeters"`
+	HeartRateBpm   *tcxHeartRate `xml:"HeartRateBpm,omitempty"`
+	Cadence        int           `xml:"Cadence,omitempty"`
+}
+
+// tcxPosition is the representation of a TCX Position.
+type tcxPosition struct {
+	LatitudeDegrees  float64 `xml:"LatitudeDegrees"`
+	LongitudeDegrees float64 `xml:"LongitudeDegrees"`
+}
+
+// tcxHeartRate is the representation of a TCX heart rate.
+type tcxHeartRate struct {
+	Value int `xml:"Value"`
+}
+
+// ToTCX returns the GPX as a TCX document. Every track is an Activity of a
+// single Lap, with a Track of Trackpoints per segment. The Activity Sport is
+// Running or Biking when the track type says so, Other otherwise. A Trackpoint
+// has the position, time, elevation, distance from the start of the track in
+// meters, and the heart rate and cadence of the Garmin TrackPointExtension.
+// Track points without timestamp and tracks without any are dropped, as TCX
+// requires them, so are the waypoints, routes, metadata and any other
+// extension.
+func (g *GPX) ToTCX() ([]byte, error) {
+	document := tcx{XMLNS: TCXNamespace, Activities: []tcxActivity{}}
+
+	for i := range g.Tracks {
+		if activity, ok := tcxTrackActivity(&g.Tracks[i]); ok {
+			document.Activities = append(document.Activities, activity)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString(xml.Header)
+
+	if err := xml.NewEncoder(&buf).Encode(document); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// tcxTrackActivity returns the track as an Activity, false when it has no
+// timestamped track point.
+func tcxTrackActivity(track *Track) (tcxActivity, bool) {
+	lap := tcxLap{
+		TotalTimeSeconds: track.Duration(),
+		DistanceMeters:   track.Length() * 1000,
+		Intensity:        "Active",
+		TriggerMethod:    "Manual",
+	}
+
+	var distance float64
+
+	for _, segment := range track.TrackSegments {
+		trackpoints := []tcxTrackpoint{}
+
+		for j := range segment.TrackPoint {
+			point := &segment.TrackPoint[j]
+
+			if j > 0 {
+				distance += segment.TrackPoint[j-1].Distance(point) * 1000
+			}
+
+			t := point.Time()
+
+			if t.IsZero() {
+				continue
+			}
+
+			if lap.StartTime == "" {
+				lap.StartTime = tcxTime(t)
+			}
+
+			trackpoints = append(trackpoints, tcxPoint(point, t, distance))
+		}
+
+		if len(trackpoints) > 0 {
+			lap.Tracks = append(lap.Tracks, tcxTrack{Trackpoints: trackpoints})
+		}
+	}
+
+	if lap.StartTime == "" {
+		return tcxActivity{}, false
+	}
+
+	return tcxActivity{Sport: tcxSport(track.Type), ID: lap.StartTime, Lap: lap}, true
+}
+
+// tcxPoint returns the track point at time t and distance meters from the
+// start of the track as a Trackpoint.
+func tcxPoint(w *WayPoint, t time.Time, distance float64) tcxTrackpoint {
+	trackpoint := tcxTrackpoint{
+		Time:           tcxTime(t),
+		Position:       tcxPosition{LatitudeDegrees: w.Latitude, LongitudeDegrees: w.Longitude},
+		DistanceMeters: distance,
+	}
+
+	if w.HasElevation() {
+		elevation := w.Elevation
+		trackpoint.AltitudeMeters = &elevation
+	}
+
+	if extension := w.trackPointExtension(); extension != nil {
+		if extension.HeartRate > 0 {
+			trackpoint.HeartRateBpm = &tcxHeartRate{Value: extension.HeartRate}
+		}
+
+		trackpoint.Cadence = extension.Cadence
+	}
+
+	return trackpoint
+}
+
+// tcxTime returns t in the UTC xsd:dateTime format of TCX.
+func tcxTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// tcxSport returns the TCX Sport of the track type, the Strava activity
+// type codes included.
+func tcxSport(kind string) string {
+	switch strings.ToLower(kind) {
+	case "running", "run", "9":
+		return "Running"
+	case "cycling", "biking", "ride", "1":
+		return "Biking"
+	}
+
+	return "Other"
+}
@@ -0,0 +1,81 @@
+package gpx
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToTCX(t *testing.T) {
+	b := openGPX("_data/garmin-tpx.gpx")
+	gpx, _ := ReadGPX(b)
+
+	output, err := gpx.ToTCX()
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(output), xml.Header))
+
+	var document tcx
+	assert.NoError(t, xml.Unmarshal(output, &document))
+
+	assert.Equal(t, TCXNamespace, document.XMLName.Space)
+	assert.Len(t, document.Activities, 1)
+
+	activity := document.Activities[0]
+
+	assert.Equal(t, "Running", activity.Sport)
+	assert.Equal(t, "2020-05-05T06:00:00Z", activity.ID)
+	assert.Equal(t, "2020-05-05T06:00:00Z", activity.Lap.StartTime)
+	assert.Equal(t, 45.0, activity.Lap.TotalTimeSeconds)
+	assert.InDelta(t, 166.79, activity.Lap.DistanceMeters, 0.01)
+	assert.Len(t, activity.Lap.Tracks, 1)
+
+	trackpoints := activity.Lap.Tracks[0].Trackpoints
+
+	assert.Len(t, trackpoints, 4)
+	assert.Equal(t, "2020-05-05T06:00:15Z", trackpoints[1].Time)
+	assert.Equal(t, tcxPosition{LatitudeDegrees: 25.0005, LongitudeDegrees: 121.5}, trackpoints[1].Position)
+	assert.Equal(t, 10.4, *trackpoints[1].AltitudeMeters)
+	assert.InDelta(t, 55.60, trackpoints[1].DistanceMeters, 0.01)
+	assert.Equal(t, 112, trackpoints[1].HeartRateBpm.Value)
+	assert.Equal(t, 84, trackpoints[1].Cadence)
+	assert.Nil(t, trackpoints[3].HeartRateBpm)
+	assert.Equal(t, 0, trackpoints[3].Cadence)
+}
+
+func TestToTCXWithoutTimestamps(t *testing.T) {
+	gpx := &GPX{Tracks: []Track{{
+		TrackSegments: []TrackSegment{{TrackPoint: []WayPoint{
+			{Latitude: 25, Longitude: 121.5},
+			{Latitude: 25.001, Longitude: 121.5, Timestamp: "2020-05-03T07:00:00+08:00"},
+		}}},
+	}, {
+		TrackSegments: []TrackSegment{{TrackPoint: []WayPoint{{Latitude: 25, Longitude: 121.5}}}},
+	}}}
+
+	output, err := gpx.ToTCX()
+
+	assert.NoError(t, err)
+
+	var document tcx
+	assert.NoError(t, xml.Unmarshal(output, &document))
+
+	assert.Len(t, document.Activities, 1)
+	assert.Equal(t, "Other", document.Activities[0].Sport)
+	assert.Equal(t, "2020-05-02T23:00:00Z", document.Activities[0].ID)
+
+	trackpoints := document.Activities[0].Lap.Tracks[0].Trackpoints
+
+	assert.Len(t, trackpoints, 1)
+	assert.Nil(t, trackpoints[0].AltitudeMeters)
+	assert.InDelta(t, 111.19, trackpoints[0].DistanceMeters, 0.01)
+}
+
+func TestToTCXEmpty(t *testing.T) {
+	output, err := (&GPX{}).ToTCX()
+
+	assert.NoError(t, err)
+	assert.Equal(t, xml.Header+`<TrainingCenterDatabase xmlns="`+TCXNamespace+`"><Activities></Activities></TrainingCenterDatabase>`, string(output))
+}
@@ -0,0 +1,879 @@
+package gpx
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Simplify returns a new GPX with every track segment simplified by the
+// Ramer-Douglas-Peucker algorithm. The first and last points of a segment
+// are kept, and points closer than epsilon meters to the simplified line
+// are dropped.
+func (g *GPX) Simplify(epsilon float64) *GPX {
+	return g.mapSegments(func(points []WayPoint) [][]WayPoint {
+		return [][]WayPoint{simplify(points, epsilon/1000)}
+	})
+}
+
+// SimplifyToCount returns a new GPX simplified like Simplify with the
+// smallest epsilon, found by binary search, keeping at most max track points.
+// The first and last points of every segment are always kept, so the result
+// exceeds max when there are more than max/2 segments.
+func (g *GPX) SimplifyToCount(max int) *GPX {
+	count := func(epsilon float64) int {
+		var n int
+
+		for _, track := range g.Tracks {
+			for _, segment := range track.TrackSegments {
+				n += len(simplify(segment.TrackPoint, epsilon/1000))
+			}
+		}
+
+		return n
+	}
+
+	if g.NumPoints() <= max {
+		return g.Clone()
+	}
+
+	// No point is farther than the total distance from the simplified line.
+	low, high := 0.0, g.Distance()*1000+1
+
+	for i := 0; i < 64 && high-low > 1e-6; i++ {
+		middle := (low + high) / 2
+
+		if count(middle) <= max {
+			high = middle
+		} else {
+			low = middle
+		}
+	}
+
+	return g.Simplify(high)
+}
+
+// simplify returns the points kept by the Ramer-Douglas-Peucker algorithm,
+// epsilon is in kilometers.
+func simplify(points []WayPoint, epsilon float64) []WayPoint {
+	if len(points) < 3 {
+		return append([]WayPoint(nil), points...)
+	}
+
+	keep := make([]bool, len(points))
+	keep[0] = true
+	keep[len(points)-1] = true
+
+	stack := [][2]int{{0, len(points) - 1}}
+
+	for len(stack) > 0 {
+		first, last := stack[len(stack)-1][0], stack[len(stack)-1][1]
+		stack = stack[:len(stack)-1]
+
+		index := -1
+		maxDistance := 0.0
+
+		for i := first + 1; i < last; i++ {
+			distance := crossTrackDistance(&points[i], &points[first], &points[last])
+
+			if distance > maxDistance {
+				index = i
+				maxDistance = distance
+			}
+		}
+
+		if index != -1 && maxDistance >= epsilon {
+			keep[index] = true
+			stack = append(stack, [2]int{first, index}, [2]int{index, last})
+		}
+	}
+
+	simplified := []WayPoint{}
+
+	for i := range points {
+		if keep[i] {
+			simplified = append(simplified, points[i])
+		}
+	}
+
+	return simplified
+}
+
+// crossTrackDistance returns the distance in kilometers from p to the great
+// circle path between start and end, or to the nearest of both ends when p
+// is not alongside the path.
+// ref: https://www.movable-type.co.uk/scripts/latlong.html
+func crossTrackDistance(p, start, end *WayPoint) float64 {
+	pathDistance := start.Distance(end)
+
+	if pathDistance == 0 {
+		return start.Distance(p)
+	}
+
+	angularDistance := start.Distance(p) / EARTHRADIUS
+	bearingDelta := toRadians(float64(start.Bearing(p)) - float64(start.Bearing(end)))
+
+	if math.Cos(bearingDelta) < 0 {
+		return start.Distance(p)
+	}
+
+	crossTrack := math.Asin(math.Sin(angularDistance) * math.Sin(bearingDelta))
+	alongTrack := math.Acos(math.Min(1, math.Cos(angularDistance)/math.Cos(crossTrack)))
+
+	if alongTrack*EARTHRADIUS > pathDistance {
+		return end.Distance(p)
+	}
+
+	return math.Abs(crossTrack) * EARTHRADIUS
+}
+
+// RemoveDuplicates returns a new GPX without the track points whose
+// coordinates and timestamp equal the ones of the previous point.
+func (g *GPX) RemoveDuplicates() *GPX {
+	return g.mapSegments(func(points []WayPoint) [][]WayPoint {
+		var unique []WayPoint
+
+		for i := range points {
+			if i > 0 && points[i].Latitude == points[i-1].Latitude &&
+				points[i].Longitude == points[i-1].Longitude &&
+				points[i].Timestamp == points[i-1].Timestamp {
+				continue
+			}
+
+			unique = append(unique, points[i])
+		}
+
+		return [][]WayPoint{unique}
+	})
+}
+
+// SortByTime returns a new GPX with the track points of every segment sorted
+// by timestamp, the points with equal timestamps kept in order. A point
+// without parseable timestamp stays after the point it follows.
+func (g *GPX) SortByTime() *GPX {
+	return g.mapSegments(func(points []WayPoint) [][]WayPoint {
+		sorted := append([]WayPoint(nil), points...)
+		times := make([]time.Time, len(points))
+
+		for i := range points {
+			times[i] = points[i].Time()
+
+			if times[i].IsZero() && i > 0 {
+				times[i] = times[i-1]
+			}
+		}
+
+		sort.Stable(byTime{points: sorted, times: times})
+
+		return [][]WayPoint{sorted}
+	})
+}
+
+// byTime sorts points by their times.
+type byTime struct {
+	points []WayPoint
+	times  []time.Time
+}
+
+func (b byTime) Len() int {
+	return len(b.points)
+}
+
+func (b byTime) Less(i, j int) bool {
+	return b.times[i].Before(b.times[j])
+}
+
+func (b byTime) Swap(i, j int) {
+	b.points[i], b.points[j] = b.points[j], b.points[i]
+	b.times[i], b.times[j] = b.times[j], b.times[i]
+}
+
+// RemoveOutliers returns a new GPX without the track points which are
+// implausible relative to their neighbors: a point is removed when both
+// reaching it from the previous kept point and leaving it to the next point
+// take a speed above maxSpeed (m/s), so a single spike is removed without
+// its neighbors. At the ends of a segment, where one neighbor is missing,
+// the point is compared with the two nearest points on the other side.
+// Points without timestamp are kept and aren't used as neighbors.
+func (g *GPX) RemoveOutliers(maxSpeed float64) *GPX {
+	return g.mapSegments(func(points []WayPoint) [][]WayPoint {
+		var timed, kept []int
+
+		for i := range points {
+			if !points[i].Time().IsZero() {
+				timed = append(timed, i)
+			}
+		}
+
+		implausible := func(a, b int) bool {
+			return points[a].SpeedTo(&points[b]) > maxSpeed
+		}
+		removed := make([]bool, len(points))
+
+		for j, i := range timed {
+			var reaching, leaving bool
+
+			switch {
+			case len(kept) > 0 && j+1 < len(timed):
+				reaching = implausible(kept[len(kept)-1], i)
+				leaving = implausible(i, timed[j+1])
+			case len(kept) == 0 && j+2 < len(timed):
+				reaching = implausible(i, timed[j+1])
+				leaving = implausible(i, timed[j+2])
+			case j+1 == len(timed) && len(kept) > 1:
+				reaching = implausible(kept[len(kept)-2], i)
+				leaving = implausible(kept[len(kept)-1], i)
+			}
+
+			if reaching && leaving {
+				removed[i] = true
+				continue
+			}
+
+			kept = append(kept, i)
+		}
+
+		result := make([]WayPoint, 0, len(points))
+
+		for i := range points {
+			if !removed[i] {
+				result = append(result, points[i])
+			}
+		}
+
+		return [][]WayPoint{result}
+	})
+}
+
+// SmoothElevation returns a new GPX where the elevation of every track point
+// is the centered moving average of the window points around it, within its
+// segment. An even window is rounded up to the next odd size, and the window
+// shrinks at the ends of a segment. Only the points with elevation (see
+// HasElevation) are averaged, and the points without stay without. A window
+// of 1 or less, zero and negative included, returns an unchanged copy.
+func (g *GPX) SmoothElevation(window int) *GPX {
+	if window <= 1 {
+		return g.Clone()
+	}
+
+	half := window / 2
+
+	return g.mapSegments(func(points []WayPoint) [][]WayPoint {
+		smoothed := append([]WayPoint(nil), points...)
+
+		for i := range points {
+			if !points[i].HasElevation() {
+				continue
+			}
+
+			start := i - half
+			end := i + half
+
+			if start < 0 {
+				start = 0
+			}
+
+			if end > len(points)-1 {
+				end = len(points) - 1
+			}
+
+			var sum float64
+			var count int
+
+			for j := start; j <= end; j++ {
+				if points[j].HasElevation() {
+					sum += points[j].Elevation
+					count++
+				}
+			}
+
+			smoothed[i].Elevation = sum / float64(count)
+			smoothed[i].ElevationSet = true
+		}
+
+		return [][]WayPoint{smoothed}
+	})
+}
+
+// FillElevationGaps returns a new GPX where the track points without
+// elevation (see HasElevation) get one interpolated linearly, by distance
+// along the path, between the nearest points of their segment with one.
+// The points before the first known elevation get the first one, and the
+// points after the last known elevation get the last one. A segment without
+// any elevation is kept as-is.
+func (g *GPX) FillElevationGaps() *GPX {
+	return g.mapSegments(func(points []WayPoint) [][]WayPoint {
+		filled := append([]WayPoint(nil), points...)
+		distances := make([]float64, len(filled))
+
+		for i := 1; i < len(filled); i++ {
+			distances[i] = distances[i-1] + filled[i-1].Distance(&filled[i])
+		}
+
+		previous := -1
+
+		for i := range filled {
+			if !filled[i].HasElevation() {
+				continue
+			}
+
+			if previous == -1 {
+				for j := 0; j < i; j++ {
+					filled[j].Elevation = filled[i].Elevation
+					filled[j].ElevationSet = true
+				}
+			} else {
+				for j := previous + 1; j < i; j++ {
+					ratio := float64(j-previous) / float64(i-previous)
+
+					if span := distances[i] - distances[previous]; span > 0 {
+						ratio = (distances[j] - distances[previous]) / span
+					}
+
+					filled[j].Elevation = filled[previous].Elevation + (filled[i].Elevation-filled[previous].Elevation)*ratio
+					filled[j].ElevationSet = true
+				}
+			}
+
+			previous = i
+		}
+
+		if previous != -1 {
+			for j := previous + 1; j < len(filled); j++ {
+				filled[j].Elevation = filled[previous].Elevation
+				filled[j].ElevationSet = true
+			}
+		}
+
+		return [][]WayPoint{filled}
+	})
+}
+
+// TrimStationaryEnds returns a new GPX without the track points before the
+// first move and after the last move at a speed of at least threshold (m/s),
+// like the time spent standing still acquiring a GPS fix. The stops in
+// between are kept. A GPX without any such move is returned unchanged.
+// StoppedSpeedThreshold is the threshold consistent with MovingTime, see
+// TrimStationaryEndsOptions to trim the moves counting as stopped.
+func (g *GPX) TrimStationaryEnds(threshold float64) *GPX {
+	if threshold == 0 {
+		threshold = AnySpeed
+	}
+
+	return g.TrimStationaryEndsOptions(MovingOptions{StoppedSpeed: threshold, MaxGap: math.MaxInt64})
+}
+
+// TrimStationaryEndsOptions is like TrimStationaryEnds, trimming the track
+// points before the first move and after the last move counting as moving
+// with the given options (see MovingTimeOptions).
+func (g *GPX) TrimStationaryEndsOptions(opts MovingOptions) *GPX {
+	opts = opts.withDefaults()
+	first, last := -1, -1
+	index := 0
+
+	for _, track := range g.Tracks {
+		for _, segment := range track.TrackSegments {
+			trackPoints := segment.TrackPoint
+
+			for i := 1; i < len(trackPoints); i++ {
+				if _, moving := opts.moving(&trackPoints[i-1], &trackPoints[i]); moving {
+					if first == -1 {
+						first = index + i - 1
+					}
+
+					last = index + i
+				}
+			}
+
+			index += len(trackPoints)
+		}
+	}
+
+	if first == -1 {
+		return g.mapSegments(func(points []WayPoint) [][]WayPoint {
+			return [][]WayPoint{append([]WayPoint(nil), points...)}
+		})
+	}
+
+	index = 0
+
+	return g.mapSegments(func(points []WayPoint) [][]WayPoint {
+		var kept []WayPoint
+
+		for i := range points {
+			if index >= first && index <= last {
+				kept = append(kept, points[i])
+			}
+
+			index++
+		}
+
+		return [][]WayPoint{kept}
+	})
+}
+
+// MergeGPX returns a new GPX holding the waypoints, routes and tracks of
+// every GPX in order. The creator, version and metadata are the ones of the
+// first GPX with one, but the metadata timestamp is the earliest of all.
+// The Namespaces of every GPX are merged, the first one wins when they
+// declare the same prefix, so the prefixes of their extensions stay declared.
+// Every GPX is copied by Clone, so the merged GPX shares nothing with them.
+func MergeGPX(gpxs ...*GPX) *GPX {
+	merged := &GPX{}
+
+	var earliest time.Time
+
+	for _, g := range gpxs {
+		if g == nil {
+			continue
+		}
+
+		g = g.Clone()
+
+		if merged.Creator == "" {
+			merged.Creator = g.Creator
+		}
+
+		if merged.Version == "" {
+			merged.Version = g.Version
+		}
+
+		if g.Metadata != nil {
+			if merged.Metadata == nil {
+				merged.Metadata = g.Metadata
+			}
+
+			if t := g.Metadata.Time(); !t.IsZero() && (earliest.IsZero() || t.Before(earliest)) {
+				earliest = t
+				merged.Metadata.Timestamp = g.Metadata.Timestamp
+			}
+		}
+
+		for prefix, namespace := range g.Namespaces {
+			if _, ok := merged.Namespaces[prefix]; !ok {
+				if merged.Namespaces == nil {
+					merged.Namespaces = map[string]string{}
+				}
+
+				merged.Namespaces[prefix] = namespace
+			}
+		}
+
+		merged.Waypoints = append(merged.Waypoints, g.Waypoints...)
+		merged.Routes = append(merged.Routes, g.Routes...)
+		merged.Tracks = append(merged.Tracks, g.Tracks...)
+	}
+
+	return merged
+}
+
+// SplitOnGaps returns a new GPX where a track segment is split between two
+// consecutive points whose time gap exceeds timeGap or whose distance
+// exceeds distGapMeters. A zero threshold disables its check, and the time
+// gap is only checked between points with timestamp.
+func (g *GPX) SplitOnGaps(timeGap time.Duration, distGapMeters float64) *GPX {
+	return g.mapSegments(func(points []WayPoint) [][]WayPoint {
+		var segments [][]WayPoint
+
+		start := 0
+
+		for i := 1; i < len(points); i++ {
+			gap := false
+
+			if timeGap > 0 {
+				previous, current := points[i-1].Time(), points[i].Time()
+				gap = !previous.IsZero() && !current.IsZero() && current.Sub(previous) > timeGap
+			}
+
+			if distGapMeters > 0 && points[i-1].Distance(&points[i])*1000 > distGapMeters {
+				gap = true
+			}
+
+			if gap {
+				segments = append(segments, append([]WayPoint(nil), points[start:i]...))
+				start = i
+			}
+		}
+
+		return append(segments, append([]WayPoint(nil), points[start:]...))
+	})
+}
+
+// CropOptions configures CropByTimeOptions.
+type CropOptions struct {
+	// KeepUntimed keeps the points without parseable timestamp instead of
+	// dropping them.
+	KeepUntimed bool
+}
+
+// CropByTime returns a new GPX with only the track points whose timestamp
+// is within [start, end], keeping the segment structure. Points without
+// parseable timestamp are dropped.
+func (g *GPX) CropByTime(start, end time.Time) *GPX {
+	return g.CropByTimeOptions(start, end, CropOptions{})
+}
+
+// CropByTimeOptions is like CropByTime with the given options.
+func (g *GPX) CropByTimeOptions(start, end time.Time, opts CropOptions) *GPX {
+	return g.mapSegments(func(points []WayPoint) [][]WayPoint {
+		var kept []WayPoint
+
+		for i := range points {
+			t := points[i].Time()
+
+			if t.IsZero() {
+				if opts.KeepUntimed {
+					kept = append(kept, points[i])
+				}
+
+				continue
+			}
+
+			if !t.Before(start) && !t.After(end) {
+				kept = append(kept, points[i])
+			}
+		}
+
+		return [][]WayPoint{kept}
+	})
+}
+
+// ClipToBounds returns a new GPX with only the track points inside the
+// bounding box, edges included. A segment is split where it leaves the box
+// and re-enters it. The points outside are dropped, boundary crossings are
+// not interpolated onto the edges of the box.
+func (g *GPX) ClipToBounds(minLat, minLon, maxLat, maxLon float64) *GPX {
+	return g.mapSegments(func(points []WayPoint) [][]WayPoint {
+		var segments [][]WayPoint
+		var current []WayPoint
+
+		for i := range points {
+			p := &points[i]
+
+			if p.Latitude >= minLat && p.Latitude <= maxLat && p.Longitude >= minLon && p.Longitude <= maxLon {
+				current = append(current, *p)
+				continue
+			}
+
+			if len(current) > 0 {
+				segments = append(segments, current)
+				current = nil
+			}
+		}
+
+		return append(segments, current)
+	})
+}
+
+// FlattenSegments returns a copy of the track with the points of all its
+// segments concatenated in order into a single segment. The pauses between
+// segments are lost, so the distance then includes the gap between the end
+// of a segment and the start of the next one. The extensions of the segments
+// are dropped, the single segment has none.
+func (t *Track) FlattenSegments() *Track {
+	result := *t
+	result.Links = cloneLinks(t.Links)
+	result.Extensions = t.Extensions.clone()
+	result.TrackSegments = flattenSegments(t.TrackSegments)
+
+	for i := range result.TrackSegments {
+		result.TrackSegments[i].TrackPoint = clonePoints(result.TrackSegments[i].TrackPoint)
+	}
+
+	return &result
+}
+
+// FlattenSegments returns a copy of the GPX, made by Clone, where every track
+// is flattened into a single segment, see Track.FlattenSegments.
+func (g *GPX) FlattenSegments() *GPX {
+	result := g.Clone()
+
+	for i := range result.Tracks {
+		result.Tracks[i].TrackSegments = flattenSegments(result.Tracks[i].TrackSegments)
+	}
+
+	return result
+}
+
+// flattenSegments returns a single segment holding the points of the
+// segments in order, or nil when they have no point.
+func flattenSegments(segments []TrackSegment) []TrackSegment {
+	var points []WayPoint
+
+	for i := range segments {
+		points = append(points, segments[i].TrackPoint...)
+	}
+
+	if len(points) == 0 {
+		return nil
+	}
+
+	return []TrackSegment{{TrackPoint: points}}
+}
+
+// Reverse returns a new GPX where the order of the points of every track
+// segment and route, and the order of the segments of every track, are
+// reversed. Timestamps are kept as-is, so the time based statistics of
+// the reversed GPX are meaningless.
+func (g *GPX) Reverse() *GPX {
+	reversed := g.mapSegments(func(points []WayPoint) [][]WayPoint {
+		return [][]WayPoint{reversePoints(points)}
+	})
+
+	for _, track := range reversed.Tracks {
+		segments := track.TrackSegments
+
+		for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
+			segments[i], segments[j] = segments[j], segments[i]
+		}
+	}
+
+	for i := range reversed.Routes {
+		reversed.Routes[i].RoutePoints = reversePoints(reversed.Routes[i].RoutePoints)
+	}
+
+	return reversed
+}
+
+// reversePoints returns a reversed copy of the points.
+func reversePoints(points []WayPoint) []WayPoint {
+	reversed := make([]WayPoint, len(points))
+
+	for i := range points {
+		reversed[len(points)-1-i] = points[i]
+	}
+
+	return reversed
+}
+
+// ResampleByTime returns a new GPX where every track segment is resampled to
+// a point every interval from its first point, interpolating the latitude,
+// longitude and elevation between the surrounding points. Resampling needs
+// timestamps in chronological order, the points without one are dropped.
+func (g *GPX) ResampleByTime(interval time.Duration) *GPX {
+	return g.mapSegments(func(points []WayPoint) [][]WayPoint {
+		var timed []WayPoint
+		var times []time.Time
+
+		for i := range points {
+			if t := points[i].Time(); !t.IsZero() {
+				timed = append(timed, points[i])
+				times = append(times, t)
+			}
+		}
+
+		if len(timed) == 0 || interval <= 0 {
+			return nil
+		}
+
+		resampled := []WayPoint{interpolate(&timed[0], &timed[0], 0, times[0])}
+		j := 0
+
+		for t := times[0].Add(interval); !t.After(times[len(times)-1]); t = t.Add(interval) {
+			for times[j+1].Before(t) {
+				j++
+			}
+
+			ratio := float64(t.Sub(times[j])) / float64(times[j+1].Sub(times[j]))
+			resampled = append(resampled, interpolate(&timed[j], &timed[j+1], ratio, t))
+		}
+
+		return [][]WayPoint{resampled}
+	})
+}
+
+// ResampleByDistance returns a new GPX where every track segment is
+// resampled to a point every meters along its path from its first point,
+// interpolating the latitude, longitude, elevation and, when both surrounding
+// points have one, the timestamp.
+func (g *GPX) ResampleByDistance(meters float64) *GPX {
+	return g.mapSegments(func(points []WayPoint) [][]WayPoint {
+		if len(points) == 0 || meters <= 0 {
+			return nil
+		}
+
+		distances := make([]float64, len(points))
+
+		for i := 1; i < len(points); i++ {
+			distances[i] = distances[i-1] + points[i-1].Distance(&points[i])*1000
+		}
+
+		total := distances[len(distances)-1]
+		resampled := []WayPoint{interpolate(&points[0], &points[0], 0, points[0].Time())}
+		j := 0
+
+		for step := 1; float64(step)*meters <= total+resampleTolerance; step++ {
+			target := math.Min(float64(step)*meters, total)
+
+			for j < len(points)-2 && distances[j+1] < target {
+				j++
+			}
+
+			ratio := 0.0
+
+			if length := distances[j+1] - distances[j]; length > 0 {
+				ratio = (target - distances[j]) / length
+			}
+
+			var t time.Time
+
+			if start, end := points[j].Time(), points[j+1].Time(); !start.IsZero() && !end.IsZero() {
+				t = start.Add(time.Duration(math.Round(ratio * float64(end.Sub(start)))))
+			}
+
+			resampled = append(resampled, interpolate(&points[j], &points[j+1], ratio, t))
+		}
+
+		return [][]WayPoint{resampled}
+	})
+}
+
+// resampleTolerance is the distance in meters under which the end of a
+// segment is considered reached, it absorbs floating point errors.
+const resampleTolerance = 1e-6
+
+// interpolate returns the point at ratio (0 to 1) of the way from a to b,
+// with the given timestamp or none when it is zero. The point only has an
+// elevation when both a and b have one (see HasElevation), or when it is at
+// a or b and that one has.
+func interpolate(a, b *WayPoint, ratio float64, t time.Time) WayPoint {
+	point := WayPoint{
+		Latitude:  a.Latitude + (b.Latitude-a.Latitude)*ratio,
+		Longitude: a.Longitude + (b.Longitude-a.Longitude)*ratio,
+	}
+
+	switch {
+	case a.HasElevation() && b.HasElevation():
+		point.Elevation = a.Elevation + (b.Elevation-a.Elevation)*ratio
+		point.ElevationSet = true
+	case ratio == 0 && a.HasElevation():
+		point.Elevation = a.Elevation
+		point.ElevationSet = true
+	case ratio == 1 && b.HasElevation():
+		point.Elevation = b.Elevation
+		point.ElevationSet = true
+	}
+
+	if !t.IsZero() {
+		point.Timestamp = t.Format(time.RFC3339Nano)
+	}
+
+	return point
+}
+
+// ShiftTime returns a new GPX with every timestamp moved by delta, the
+// metadata time and the times of the waypoints, route points and track
+// points, to fix a recording made with the device clock set wrong. The
+// timestamps keep their layout, offset and fractional seconds, and the ones
+// missing or unparseable are left unchanged.
+func (g *GPX) ShiftTime(delta time.Duration) *GPX {
+	return g.mapTimestamps(func(t time.Time) time.Time {
+		return t.Add(delta)
+	})
+}
+
+// SetTimezone returns a new GPX with every timestamp, like the ones moved by
+// ShiftTime, written in the time zone loc, e.g. 2020-05-03T15:00:00+08:00
+// instead of 2020-05-03T07:00:00Z, for the tools displaying the local time
+// from the raw timestamp. Only the offset changes, not the instant: ReadGPX
+// and Time parse both to the same instant, in a time.Time with the offset of
+// the timestamp. A timestamp without offset, read as UTC, gets one unless
+// loc is UTC.
+func (g *GPX) SetTimezone(loc *time.Location) *GPX {
+	return g.mapTimestamps(func(t time.Time) time.Time {
+		return t.In(loc)
+	})
+}
+
+// mapTimestamps returns a copy of the GPX where every parseable timestamp is
+// replaced by the one returned by fn, formatted like the original.
+func (g *GPX) mapTimestamps(fn func(t time.Time) time.Time) *GPX {
+	result := g.Clone()
+	mapPoints := func(points []WayPoint) {
+		for i := range points {
+			points[i].Timestamp = mapTimestamp(points[i].Timestamp, fn)
+		}
+	}
+
+	if result.Metadata != nil {
+		result.Metadata.Timestamp = mapTimestamp(result.Metadata.Timestamp, fn)
+	}
+
+	mapPoints(result.Waypoints)
+
+	for _, route := range result.Routes {
+		mapPoints(route.RoutePoints)
+	}
+
+	for _, track := range result.Tracks {
+		for _, segment := range track.TrackSegments {
+			mapPoints(segment.TrackPoint)
+		}
+	}
+
+	return result
+}
+
+// mapTimestamp returns the timestamp replaced by the one returned by fn in
+// the layout it was parsed with and with as many fractional second digits,
+// or the timestamp itself when it can't be parsed. A layout without offset
+// is replaced by RFC 3339 when the new time isn't in UTC.
+func mapTimestamp(value string, fn func(t time.Time) time.Time) string {
+	for _, layout := range timeLayouts {
+		t, err := time.Parse(layout, value)
+
+		if err != nil {
+			continue
+		}
+
+		t = fn(t)
+
+		if _, offset := t.Zone(); offset != 0 && !strings.Contains(layout, "Z") {
+			layout = time.RFC3339
+		}
+
+		if i := strings.Index(value, "."); i != -1 && !strings.Contains(layout, ".") {
+			digits := len(value[i+1:]) - len(strings.TrimLeft(value[i+1:], "0123456789"))
+			layout = strings.Replace(layout, "05", "05."+strings.Repeat("0", digits), 1)
+		}
+
+		return t.Format(layout)
+	}
+
+	return value
+}
+
+// mapSegments returns a copy of the GPX, made by Clone, where the points of
+// every track segment are replaced by the segments returned by fn. Empty
+// segments returned by fn are dropped, segments which were already empty are
+// kept as they are.
+func (g *GPX) mapSegments(fn func(points []WayPoint) [][]WayPoint) *GPX {
+	result := g.Clone()
+
+	for i := range result.Tracks {
+		track := &result.Tracks[i]
+		segments := track.TrackSegments
+		track.TrackSegments = nil
+
+		for _, segment := range segments {
+			if len(segment.TrackPoint) == 0 {
+				track.TrackSegments = append(track.TrackSegments, segment)
+				continue
+			}
+
+			for _, points := range fn(segment.TrackPoint) {
+				if len(points) == 0 {
+					continue
+				}
+
+				newSegment := segment
+				newSegment.TrackPoint = points
+				newSegment.Extensions = segment.Extensions.clone()
+				track.TrackSegments = append(track.TrackSegments, newSegment)
+			}
+		}
+	}
+
+	return result
+}
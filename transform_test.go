@@ -0,0 +1,818 @@
+package gpx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestGPX returns a GPX with a single track holding the given segments.
+func newTestGPX(segments ...[]WayPoint) *GPX {
+	track := Track{}
+
+	for _, points := range segments {
+		track.TrackSegments = append(track.TrackSegments, TrackSegment{TrackPoint: points})
+	}
+
+	return &GPX{Tracks: []Track{track}}
+}
+
+func TestSimplifyStraightLine(t *testing.T) {
+	var points []WayPoint
+
+	for i := 0; i <= 10; i++ {
+		points = append(points, WayPoint{Latitude: 25 + float64(i)*0.001, Longitude: 121.5})
+	}
+
+	gpx := newTestGPX(points)
+	simplified := gpx.Simplify(1)
+
+	assert.Len(t, simplified.Tracks[0].TrackSegments[0].TrackPoint, 2)
+	assert.Equal(t, points[0], simplified.Tracks[0].TrackSegments[0].TrackPoint[0])
+	assert.Equal(t, points[10], simplified.Tracks[0].TrackSegments[0].TrackPoint[1])
+	assert.Len(t, gpx.Tracks[0].TrackSegments[0].TrackPoint, 11)
+}
+
+func TestSimplifyCurve(t *testing.T) {
+	var points []WayPoint
+
+	// A half circle of about 1km radius.
+	for i := 0; i <= 18; i++ {
+		angle := float64(i) * math.Pi / 18
+		points = append(points, WayPoint{
+			Latitude:  25 + 0.009*math.Sin(angle),
+			Longitude: 121.5 + 0.01*math.Cos(angle),
+		})
+	}
+
+	gpx := newTestGPX(points)
+	simplified := gpx.Simplify(20).Tracks[0].TrackSegments[0].TrackPoint
+
+	assert.Less(t, len(simplified), len(points))
+	assert.Greater(t, len(simplified), 5)
+	assert.Equal(t, points[0], simplified[0])
+	assert.Equal(t, points[18], simplified[len(simplified)-1])
+	assert.Contains(t, simplified, points[9])
+}
+
+func TestSimplifyKeepsSegments(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	simplified := gpx.Simplify(5)
+
+	assert.Len(t, simplified.Tracks[0].TrackSegments, 2)
+	assert.Len(t, simplified.Tracks[0].TrackSegments[0].TrackPoint, 2)
+	assert.Len(t, simplified.Tracks[0].TrackSegments[1].TrackPoint, 2)
+	assert.InDelta(t, gpx.Distance(), simplified.Distance(), 1e-9)
+}
+
+func TestTransformsCopyTheGPX(t *testing.T) {
+	gpx := newTestGPX(
+		[]WayPoint{{Latitude: 25, Longitude: 121.5}, {Latitude: 25.001, Longitude: 121.5}},
+		[]WayPoint{},
+	)
+	gpx.Metadata = &MetaData{Name: "Morning Ride"}
+	gpx.Waypoints = []WayPoint{{Name: "Start"}}
+	gpx.Routes = []Route{{Name: "Loop", RoutePoints: []WayPoint{{Name: "A"}, {Name: "B"}}}}
+	gpx.Tracks[0].Links = []Link{{URL: "https://example.com"}}
+
+	for _, transformed := range []*GPX{gpx.Simplify(5), gpx.Reverse(), gpx.RemoveDuplicates()} {
+		assert.Len(t, transformed.Tracks[0].TrackSegments, 2)
+		assert.Len(t, transformed.Points(), 2)
+
+		transformed.Metadata.Name = "Evening Ride"
+		transformed.Waypoints[0].Name = "End"
+		transformed.Routes[0].RoutePoints[0].Name = "C"
+		transformed.Tracks[0].Links[0].URL = "https://example.org"
+	}
+
+	assert.Equal(t, "Morning Ride", gpx.Metadata.Name)
+	assert.Equal(t, "Start", gpx.Waypoints[0].Name)
+	assert.Equal(t, "A", gpx.Routes[0].RoutePoints[0].Name)
+	assert.Equal(t, "https://example.com", gpx.Tracks[0].Links[0].URL)
+}
+
+func TestSimplifyToCount(t *testing.T) {
+	var points []WayPoint
+
+	for i := 0; i < 1000; i++ {
+		points = append(points, WayPoint{
+			Latitude:  25 + float64(i)*0.0001,
+			Longitude: 121.5 + 0.005*math.Sin(float64(i)/50),
+		})
+	}
+
+	gpx := newTestGPX(points)
+	simplified := gpx.SimplifyToCount(10).Points()
+
+	assert.True(t, len(simplified) <= 10)
+	assert.True(t, len(simplified) >= 8)
+	assert.Equal(t, points[0], simplified[0])
+	assert.Equal(t, points[999], simplified[len(simplified)-1])
+	assert.Len(t, gpx.SimplifyToCount(2).Points(), 2)
+	assert.Len(t, gpx.SimplifyToCount(1).Points(), 2)
+	assert.Len(t, gpx.SimplifyToCount(1000).Points(), 1000)
+}
+
+func TestCrossTrackDistance(t *testing.T) {
+	start := WayPoint{Latitude: 0, Longitude: 0}
+	end := WayPoint{Latitude: 0, Longitude: 1}
+	above := WayPoint{Latitude: 0.01, Longitude: 0.5}
+	before := WayPoint{Latitude: 0, Longitude: -0.01}
+
+	assert.InDelta(t, above.Distance(&WayPoint{Latitude: 0, Longitude: 0.5}), crossTrackDistance(&above, &start, &end), 1e-6)
+	assert.InDelta(t, start.Distance(&before), crossTrackDistance(&before, &start, &end), 1e-9)
+}
+
+func TestRemoveDuplicates(t *testing.T) {
+	b := openGPX("_data/duplicates.gpx")
+	gpx, _ := ReadGPX(b)
+
+	unique := gpx.RemoveDuplicates()
+	points := unique.Points()
+
+	assert.Len(t, points, 4)
+	assert.Equal(t, "2020-05-03T07:00:30Z", points[1].Timestamp)
+	assert.Equal(t, "2020-05-03T07:00:40Z", points[2].Timestamp)
+	assert.Equal(t, "2020-05-03T07:01:00Z", points[3].Timestamp)
+	assert.Equal(t, gpx.Distance(), unique.Distance())
+	assert.Len(t, gpx.Points(), 7)
+}
+
+func TestRemoveOutliers(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5, Timestamp: "2020-05-03T07:00:00Z"},
+		{Latitude: 25.001, Longitude: 121.5, Timestamp: "2020-05-03T07:00:30Z"},
+		{Latitude: 25.010, Longitude: 121.5, Timestamp: "2020-05-03T07:01:00Z"},
+		{Latitude: 25.002, Longitude: 121.5, Timestamp: "2020-05-03T07:01:30Z"},
+		{Latitude: 25.003, Longitude: 121.5, Timestamp: "2020-05-03T07:02:00Z"},
+	})
+
+	filtered := gpx.RemoveOutliers(10)
+	points := filtered.Points()
+
+	assert.Len(t, points, 4)
+	assert.Equal(t, 25.002, points[2].Latitude)
+	assert.InDelta(t, 0.3336, filtered.Distance(), 0.001)
+	assert.Greater(t, gpx.Distance(), 2.0)
+}
+
+func TestRemoveOutliersAtSegmentEnds(t *testing.T) {
+	start := time.Date(2020, 5, 3, 7, 0, 0, 0, time.UTC)
+	spike := WayPoint{Latitude: 25.010, Longitude: 121.5, Timestamp: start.Format(time.RFC3339)}
+	points := []WayPoint{spike}
+
+	for i := 1; i < 10; i++ {
+		points = append(points, WayPoint{
+			Latitude:  25 + float64(i)*0.00001,
+			Longitude: 121.5,
+			Timestamp: start.Add(time.Duration(i) * time.Second).Format(time.RFC3339),
+		})
+	}
+
+	assert.Equal(t, points[1:], newTestGPX(points).RemoveOutliers(10).Points())
+
+	last := spike
+	last.Timestamp = start.Add(10 * time.Second).Format(time.RFC3339)
+
+	withLast := append(append([]WayPoint(nil), points[1:]...), last)
+
+	assert.Equal(t, points[1:], newTestGPX(withLast).RemoveOutliers(10).Points())
+}
+
+func TestRemoveOutliersWithoutTimestamp(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5, Timestamp: "2020-05-03T07:00:00Z"},
+		{Latitude: 25.001, Longitude: 121.5},
+		{Latitude: 25.010, Longitude: 121.5, Timestamp: "2020-05-03T07:00:30Z"},
+		{Latitude: 25.001, Longitude: 121.5, Timestamp: "2020-05-03T07:01:00Z"},
+		{Latitude: 25.002, Longitude: 121.5, Timestamp: "2020-05-03T07:01:30Z"},
+	})
+	points := gpx.Points()
+
+	assert.Equal(t, []WayPoint{points[0], points[1], points[3], points[4]}, gpx.RemoveOutliers(10).Points())
+}
+
+func TestRemoveOutliersKeepsPlausiblePoints(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, gpx.Points(), gpx.RemoveOutliers(10).Points())
+}
+
+func TestSmoothElevation(t *testing.T) {
+	var points []WayPoint
+
+	for i := 0; i < 20; i++ {
+		elevation := 100.0
+
+		if i%2 == 1 {
+			elevation = 104
+		}
+
+		points = append(points, WayPoint{Latitude: 25 + float64(i)*0.001, Longitude: 121.5, Elevation: elevation})
+	}
+
+	gpx := newTestGPX(points)
+	smoothed := gpx.SmoothElevation(5)
+	elevations := smoothed.Elevations()
+
+	assert.Len(t, elevations, 20)
+
+	for i := 2; i < 18; i++ {
+		assert.InDelta(t, 102, elevations[i], 0.81)
+	}
+
+	assert.InDelta(t, 101.333, elevations[0], 0.001)
+	assert.Less(t, smoothed.ElevationGain(), gpx.ElevationGain()/5)
+	assert.Equal(t, 104.0, gpx.Elevations()[1])
+}
+
+func TestSmoothElevationGap(t *testing.T) {
+	b := openGPX("_data/elevation-gap.gpx")
+	gpx, _ := ReadGPX(b)
+
+	points := gpx.SmoothElevation(3).Points()
+
+	assert.Equal(t, 100.0, points[0].Elevation)
+	assert.False(t, points[1].HasElevation())
+	assert.Equal(t, 105.0, points[2].Elevation)
+	assert.Equal(t, 105.0, points[3].Elevation)
+
+	// Elevations set from code, without ElevationSet.
+	points = newTestGPX([]WayPoint{{Elevation: 10}, {Elevation: -10}}).SmoothElevation(3).Points()
+
+	assert.True(t, points[0].HasElevation())
+	assert.Equal(t, 0.0, points[0].Elevation)
+}
+
+func TestSmoothElevationWindowOne(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, gpx.Elevations(), gpx.SmoothElevation(1).Elevations())
+}
+
+func TestSmoothElevationWindowZeroAndNegative(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, gpx, gpx.SmoothElevation(0))
+	assert.Equal(t, gpx, gpx.SmoothElevation(-3))
+	assert.Equal(t, gpx, gpx.SmoothElevation(1))
+}
+
+func TestTrimStationaryEnds(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5, Timestamp: "2020-05-03T07:00:00Z"},
+		{Latitude: 25.00001, Longitude: 121.5, Timestamp: "2020-05-03T07:00:30Z"},
+		{Latitude: 25.00002, Longitude: 121.5, Timestamp: "2020-05-03T07:01:00Z"},
+		{Latitude: 25.001, Longitude: 121.5, Timestamp: "2020-05-03T07:01:30Z"},
+		{Latitude: 25.001, Longitude: 121.5, Timestamp: "2020-05-03T07:02:00Z"},
+		{Latitude: 25.002, Longitude: 121.5, Timestamp: "2020-05-03T07:02:30Z"},
+	}, []WayPoint{
+		{Latitude: 25.003, Longitude: 121.5, Timestamp: "2020-05-03T07:05:00Z"},
+		{Latitude: 25.004, Longitude: 121.5, Timestamp: "2020-05-03T07:05:30Z"},
+		{Latitude: 25.004, Longitude: 121.5, Timestamp: "2020-05-03T07:06:00Z"},
+	})
+
+	trimmed := gpx.TrimStationaryEnds(1)
+	points := gpx.Points()
+
+	assert.Len(t, trimmed.Tracks[0].TrackSegments, 2)
+	assert.Equal(t, points[2:6], trimmed.Tracks[0].TrackSegments[0].TrackPoint)
+	assert.Equal(t, points[6:8], trimmed.Tracks[0].TrackSegments[1].TrackPoint)
+	assert.Equal(t, 270.0, trimmed.Duration())
+	assert.Equal(t, 360.0, gpx.Duration())
+}
+
+func TestTrimStationaryEndsOptions(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5, Timestamp: "2020-05-03T07:00:00Z"},
+		{Latitude: 25.000, Longitude: 121.5, Timestamp: "2020-05-03T07:00:30Z"},
+		{Latitude: 25.001, Longitude: 121.5, Timestamp: "2020-05-03T07:01:00Z"},
+		{Latitude: 25.002, Longitude: 121.5, Timestamp: "2020-05-03T07:05:00Z"},
+	})
+	points := gpx.Points()
+
+	assert.Equal(t, points[1:3], gpx.TrimStationaryEndsOptions(MovingOptions{}).Points())
+	assert.Equal(t, points[1:4], gpx.TrimStationaryEndsOptions(MovingOptions{StoppedSpeed: 0.3, MaxGap: 5 * time.Minute}).Points())
+	assert.Equal(t, points, gpx.TrimStationaryEndsOptions(MovingOptions{StoppedSpeed: AnySpeed, MaxGap: time.Hour}).Points())
+	assert.Equal(t, points, gpx.TrimStationaryEnds(0).Points())
+	assert.Equal(t, points[1:4], gpx.TrimStationaryEnds(0.3).Points())
+}
+
+func TestTrimStationaryEndsWithoutMove(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	assert.Equal(t, gpx.Points(), gpx.TrimStationaryEnds(100).Points())
+}
+
+func TestMergeGPX(t *testing.T) {
+	b := openGPX(testGPX)
+	strava, _ := ReadGPX(b)
+
+	b = openGPX("_data/two-segments.gpx")
+	twoSegments, _ := ReadGPX(b)
+
+	b = openGPX("_data/waypoints.gpx")
+	waypoints, _ := ReadGPX(b)
+
+	b = openGPX("_data/route.gpx")
+	route, _ := ReadGPX(b)
+
+	merged := MergeGPX(twoSegments, nil, strava, waypoints, route)
+
+	assert.Equal(t, "StravaGPX", merged.Creator)
+	assert.Equal(t, "1.1", merged.Version)
+	assert.Len(t, merged.Tracks, 2)
+	assert.Equal(t, "Two Segments", merged.Tracks[0].Name)
+	assert.Equal(t, "Strava Running Sample", merged.Tracks[1].Name)
+	assert.Len(t, merged.Waypoints, 3)
+	assert.Len(t, merged.Routes, 1)
+	assert.Equal(t, "2019-10-26T21:21:11Z", merged.Metadata.Timestamp)
+	assert.Equal(t, "2020-05-03T07:00:00Z", twoSegments.Metadata.Timestamp)
+
+	assert.InDelta(t, strava.Distance()+twoSegments.Distance(), merged.Distance(), 1e-12)
+	assert.Len(t, merged.Points(), 21)
+}
+
+func TestMergeGPXCopiesInputs(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	twoSegments, _ := ReadGPX(b)
+
+	b = openGPX("_data/route.gpx")
+	route, _ := ReadGPX(b)
+
+	points := twoSegments.Points()
+	name := twoSegments.Metadata.Name
+	routeName := route.Routes[0].RoutePoints[0].Name
+
+	merged := MergeGPX(twoSegments, route)
+	merged.Tracks[0].TrackSegments[0].TrackPoint[0].Latitude = 0
+	merged.Tracks[0].TrackSegments[0].TrackPoint = append(merged.Tracks[0].TrackSegments[0].TrackPoint[:1], WayPoint{Latitude: 1})
+	merged.Metadata.Name = "Merged"
+	merged.Routes[0].RoutePoints[0].Name = "Merged"
+
+	assert.Equal(t, points, twoSegments.Points())
+	assert.Equal(t, name, twoSegments.Metadata.Name)
+	assert.Equal(t, routeName, route.Routes[0].RoutePoints[0].Name)
+}
+
+func TestMergeGPXNamespaces(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	twoSegments, _ := ReadGPX(b)
+
+	b = openGPX("_data/garmin-connect.gpx")
+	garminConnect, _ := ReadGPX(b)
+
+	merged := MergeGPX(twoSegments, garminConnect)
+
+	assert.Equal(t, GpxExtensionsNamespace, merged.Namespaces["ns2"])
+	assert.Equal(t, GPXNamespace, merged.Namespaces[""])
+
+	var buf bytes.Buffer
+	err := WriteGPX(&buf, merged)
+
+	assert.NoError(t, err)
+
+	spaces := map[string]string{}
+	d := xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+
+	for {
+		token, err := d.Token()
+
+		if err != nil {
+			break
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			spaces[start.Name.Local] = start.Name.Space
+		}
+	}
+
+	assert.Equal(t, GpxExtensionsNamespace, spaces["TrackExtension"])
+	assert.Equal(t, GpxExtensionsNamespace, spaces["DisplayColor"])
+
+	reread, err := ReadGPX(&buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, garminConnect.Tracks[0].Extensions, reread.Tracks[1].Extensions)
+}
+
+func TestMergeGPXEmpty(t *testing.T) {
+	merged := MergeGPX()
+
+	assert.Nil(t, merged.Metadata)
+	assert.Empty(t, merged.Tracks)
+}
+
+func TestFillElevationGaps(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5},
+		{Latitude: 25.001, Longitude: 121.5, Elevation: 10},
+		{Latitude: 25.002, Longitude: 121.5},
+		{Latitude: 25.003, Longitude: 121.5},
+		{Latitude: 25.005, Longitude: 121.5},
+		{Latitude: 25.006, Longitude: 121.5, Elevation: 20},
+		{Latitude: 25.007, Longitude: 121.5},
+	}, []WayPoint{
+		{Latitude: 25.010, Longitude: 121.5},
+	})
+
+	filled := gpx.FillElevationGaps()
+	elevations := filled.Elevations()
+
+	assert.Equal(t, 10.0, elevations[0])
+	assert.Equal(t, 10.0, elevations[1])
+	assert.InDelta(t, 12, elevations[2], 1e-6)
+	assert.InDelta(t, 14, elevations[3], 1e-6)
+	assert.InDelta(t, 18, elevations[4], 1e-6)
+	assert.Equal(t, 20.0, elevations[5])
+	assert.Equal(t, 20.0, elevations[6])
+	assert.Equal(t, 0.0, elevations[7])
+	assert.Equal(t, 0.0, gpx.Elevations()[2])
+}
+
+func TestFillElevationGapsAtSeaLevel(t *testing.T) {
+	b := openGPX("_data/missing-elevation.gpx")
+	gpx, _ := ReadGPX(b)
+
+	filled := gpx.FillElevationGaps().Points()
+
+	assert.InDelta(t, 2, filled[1].Elevation, 1e-6)
+	assert.True(t, filled[1].HasElevation())
+	assert.Equal(t, 0.0, filled[0].Elevation)
+}
+
+func TestSplitOnGapsTime(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	flat := newTestGPX(gpx.Points())
+	split := flat.SplitOnGaps(time.Minute, 0)
+
+	assert.Len(t, flat.Tracks[0].TrackSegments, 1)
+	assert.Len(t, split.Tracks[0].TrackSegments, 2)
+	assert.Equal(t, gpx.Tracks[0].TrackSegments[0].TrackPoint, split.Tracks[0].TrackSegments[0].TrackPoint)
+	assert.Equal(t, gpx.Tracks[0].TrackSegments[1].TrackPoint, split.Tracks[0].TrackSegments[1].TrackPoint)
+}
+
+func TestSplitOnGapsDistance(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	flat := newTestGPX(gpx.Points())
+
+	assert.Len(t, flat.SplitOnGaps(0, 500).Tracks[0].TrackSegments, 2)
+	assert.Len(t, flat.SplitOnGaps(0, 1000).Tracks[0].TrackSegments, 1)
+	assert.InDelta(t, gpx.Distance(), flat.SplitOnGaps(0, 500).Distance(), 1e-12)
+}
+
+func TestSplitOnGapsNoGap(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	split := gpx.SplitOnGaps(time.Minute, 1000)
+
+	assert.Len(t, split.Tracks[0].TrackSegments, 1)
+	assert.Equal(t, gpx.Points(), split.Points())
+}
+
+func TestCropByTime(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	start := time.Date(2020, 5, 3, 7, 0, 30, 0, time.UTC)
+	end := time.Date(2020, 5, 3, 7, 5, 30, 0, time.UTC)
+	cropped := gpx.CropByTime(start, end)
+	points := gpx.Points()
+
+	assert.Len(t, cropped.Tracks[0].TrackSegments, 2)
+	assert.Equal(t, points[1:3], cropped.Tracks[0].TrackSegments[0].TrackPoint)
+	assert.Equal(t, points[3:5], cropped.Tracks[0].TrackSegments[1].TrackPoint)
+	assert.Equal(t, end.Sub(start).Seconds(), cropped.Duration())
+	assert.Len(t, gpx.Points(), 6)
+
+	outside := gpx.CropByTime(end.Add(time.Hour), end.Add(2*time.Hour))
+
+	assert.Len(t, outside.Tracks[0].TrackSegments, 0)
+}
+
+func TestCropByTimeUntimed(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25, Longitude: 121.5, Timestamp: "2020-05-03T07:00:00Z"},
+		{Latitude: 25.001, Longitude: 121.5},
+		{Latitude: 25.002, Longitude: 121.5, Timestamp: "2020-05-03T07:01:00Z"},
+	})
+
+	start := time.Date(2020, 5, 3, 7, 0, 0, 0, time.UTC)
+	end := start.Add(time.Minute)
+
+	assert.Len(t, gpx.CropByTime(start, end).Points(), 2)
+	assert.Len(t, gpx.CropByTimeOptions(start, end, CropOptions{KeepUntimed: true}).Points(), 3)
+}
+
+func TestClipToBounds(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5},
+		{Latitude: 25.001, Longitude: 121.5},
+		{Latitude: 25.001, Longitude: 121.6},
+		{Latitude: 25.002, Longitude: 121.5},
+		{Latitude: 25.010, Longitude: 121.5},
+	})
+
+	clipped := gpx.ClipToBounds(25, 121.4, 25.005, 121.55)
+	points := gpx.Tracks[0].TrackSegments[0].TrackPoint
+
+	assert.Len(t, clipped.Tracks[0].TrackSegments, 2)
+	assert.Equal(t, points[0:2], clipped.Tracks[0].TrackSegments[0].TrackPoint)
+	assert.Equal(t, points[3:4], clipped.Tracks[0].TrackSegments[1].TrackPoint)
+	assert.Len(t, gpx.ClipToBounds(0, 0, 1, 1).Tracks[0].TrackSegments, 0)
+}
+
+func TestFlattenSegments(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	track := gpx.Tracks[0].FlattenSegments()
+
+	assert.Len(t, gpx.Tracks[0].TrackSegments, 2)
+	assert.Len(t, track.TrackSegments, 1)
+	assert.Equal(t, gpx.Tracks[0].Name, track.Name)
+	assert.Equal(t, gpx.Points(), track.TrackSegments[0].TrackPoint)
+	assert.True(t, track.Length() > gpx.Tracks[0].Length())
+
+	flattened := gpx.FlattenSegments()
+
+	assert.Len(t, flattened.Tracks[0].TrackSegments, 1)
+	assert.Equal(t, gpx.Points(), flattened.Points())
+	assert.Len(t, (&Track{}).FlattenSegments().TrackSegments, 0)
+}
+
+func TestFlattenSegmentsCopiesTheGPX(t *testing.T) {
+	b := openGPX("_data/garmin-tpx.gpx")
+	gpx, _ := ReadGPX(b)
+	gpx.Waypoints = []WayPoint{{Name: "Start"}}
+	gpx.Routes = []Route{{RoutePoints: []WayPoint{{Name: "A"}}}}
+	gpx.Tracks[0].Links = []Link{{URL: "https://example.com"}}
+
+	heartRate := gpx.Points()[0].Extensions.TrackPointExtensions.HeartRate
+	flattened := gpx.FlattenSegments()
+	flattened.Metadata.Links[0].URL = "https://example.org"
+	flattened.Waypoints[0].Name = "End"
+	flattened.Routes[0].RoutePoints[0].Name = "B"
+	flattened.Tracks[0].Links[0].URL = "https://example.org"
+	flattened.Tracks[0].TrackSegments[0].TrackPoint[0].Extensions.TrackPointExtensions.HeartRate = 200
+
+	track := gpx.Tracks[0].FlattenSegments()
+	track.Links[0].URL = "https://example.org"
+	track.TrackSegments[0].TrackPoint[0].Extensions.TrackPointExtensions.HeartRate = 200
+
+	assert.Equal(t, "connect.garmin.com", gpx.Metadata.Links[0].URL)
+	assert.Equal(t, "Start", gpx.Waypoints[0].Name)
+	assert.Equal(t, "A", gpx.Routes[0].RoutePoints[0].Name)
+	assert.Equal(t, "https://example.com", gpx.Tracks[0].Links[0].URL)
+	assert.Equal(t, heartRate, gpx.Points()[0].Extensions.TrackPointExtensions.HeartRate)
+}
+
+func TestReverse(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	reversed := gpx.Reverse()
+	points := gpx.Points()
+	reversedPoints := reversed.Points()
+
+	assert.Len(t, reversedPoints, len(points))
+
+	for i := range points {
+		assert.Equal(t, points[i], reversedPoints[len(points)-1-i])
+	}
+
+	assert.Equal(t, 25.012, reversed.Tracks[0].TrackSegments[0].TrackPoint[0].Latitude)
+	assert.InDelta(t, gpx.Distance(), reversed.Distance(), 1e-12)
+	assert.Equal(t, 25.0, gpx.Points()[0].Latitude)
+}
+
+func TestReverseRoutes(t *testing.T) {
+	b := openGPX("_data/route.gpx")
+	gpx, _ := ReadGPX(b)
+
+	reversed := gpx.Reverse()
+
+	assert.Equal(t, gpx.Routes[0].RoutePoints[3], reversed.Routes[0].RoutePoints[0])
+	assert.Equal(t, gpx.Routes[0].RoutePoints[0], reversed.Routes[0].RoutePoints[3])
+	assert.Equal(t, gpx.Routes[0].Name, reversed.Routes[0].Name)
+	assert.Equal(t, 5.0, gpx.Routes[0].RoutePoints[0].Elevation)
+}
+
+func TestResampleByTime(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	resampled := gpx.ResampleByTime(2 * time.Second)
+	points := resampled.Points()
+
+	assert.Len(t, points, 18)
+	assert.Equal(t, gpx.Duration(), resampled.Duration())
+
+	for i := 1; i < len(points); i++ {
+		assert.Equal(t, 2*time.Second, points[i].Time().Sub(points[i-1].Time()))
+	}
+
+	// 21:21:13 is 1 second after 21:21:12 out of the 5 seconds to 21:21:17.
+	second := gpx.Points()[1]
+	third := gpx.Points()[2]
+
+	assert.Equal(t, "2019-10-26T21:21:13Z", points[1].Timestamp)
+	assert.InDelta(t, second.Latitude+(third.Latitude-second.Latitude)/5, points[1].Latitude, 1e-12)
+	assert.InDelta(t, 15.24, points[1].Elevation, 1e-9)
+}
+
+func TestResampleByTimeElevationGap(t *testing.T) {
+	b := openGPX("_data/elevation-gap.gpx")
+	gpx, _ := ReadGPX(b)
+
+	points := gpx.ResampleByTime(10 * time.Second).Points()
+
+	assert.Len(t, points, 10)
+	assert.Equal(t, 100.0, points[0].Elevation)
+	assert.False(t, points[1].HasElevation())
+	assert.False(t, points[5].HasElevation())
+	assert.Equal(t, 100.0, points[6].Elevation)
+	assert.InDelta(t, 103.333, points[7].Elevation, 0.001)
+
+	// Elevations set from code, without ElevationSet.
+	gpx = newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5, Elevation: 10, Timestamp: "2020-05-03T07:00:00Z"},
+		{Latitude: 25.001, Longitude: 121.5, Elevation: 20, Timestamp: "2020-05-03T07:00:20Z"},
+	})
+	points = gpx.ResampleByTime(10 * time.Second).Points()
+
+	assert.Equal(t, 15.0, points[1].Elevation)
+	assert.True(t, points[1].ElevationSet)
+}
+
+func TestResampleByTimeWithoutTimestamps(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5},
+		{Latitude: 25.001, Longitude: 121.5},
+	})
+
+	resampled := gpx.ResampleByTime(time.Second)
+
+	assert.Empty(t, resampled.Points())
+	assert.Empty(t, resampled.Tracks[0].TrackSegments)
+}
+
+func TestResampleByDistance(t *testing.T) {
+	start := WayPoint{Latitude: 25, Longitude: 121.5, Elevation: 10, Timestamp: "2020-05-03T07:00:00Z"}
+	p := start.Destination(0, 0.1)
+	end := WayPoint{Latitude: p.Latitude, Longitude: p.Longitude, Elevation: 20, Timestamp: "2020-05-03T07:01:40Z"}
+
+	gpx := newTestGPX([]WayPoint{start, end})
+	points := gpx.ResampleByDistance(10).Points()
+
+	assert.Len(t, points, 11)
+
+	for i := 1; i < len(points); i++ {
+		assert.InDelta(t, 0.01, points[i-1].Distance(&points[i]), 1e-9)
+	}
+
+	assert.InDelta(t, 15, points[5].Elevation, 1e-9)
+	assert.Equal(t, "2020-05-03T07:00:50Z", points[5].Timestamp)
+	assert.InDelta(t, end.Latitude, points[10].Latitude, 1e-12)
+}
+
+func TestResampleByDistanceMultiplePoints(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	resampled := gpx.ResampleByDistance(5)
+	points := resampled.Points()
+
+	assert.Len(t, points, int(gpx.Distance()*1000/5)+1)
+	assert.Equal(t, gpx.Points()[0].Latitude, points[0].Latitude)
+	assert.InDelta(t, gpx.Distance(), resampled.Distance(), 0.005)
+}
+
+func TestResampleByDistanceWithoutTimestamps(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 25.000, Longitude: 121.5},
+		{Latitude: 25.001, Longitude: 121.5},
+	})
+
+	points := gpx.ResampleByDistance(50).Points()
+
+	assert.Len(t, points, 3)
+	assert.Equal(t, "", points[1].Timestamp)
+}
+
+func TestSortByTime(t *testing.T) {
+	b := openGPX("_data/out-of-order.gpx")
+	gpx, _ := ReadGPX(b)
+	points := gpx.Points()
+
+	result := gpx.SortByTime()
+
+	assert.Len(t, result.Tracks[0].TrackSegments, 2)
+	assert.Equal(t, []WayPoint{points[0], points[2], points[1], points[3], points[4], points[5]}, result.Tracks[0].TrackSegments[0].TrackPoint)
+	assert.Equal(t, gpx.Tracks[0].TrackSegments[1], result.Tracks[0].TrackSegments[1])
+	assert.Equal(t, 25.002, gpx.Tracks[0].TrackSegments[0].TrackPoint[1].Latitude)
+}
+
+func TestSortByTimeUntimed(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Latitude: 1, Timestamp: "2020-05-03T07:00:20Z"},
+		{Latitude: 2},
+		{Latitude: 3, Timestamp: "2020-05-03T07:00:10Z"},
+	})
+
+	points := gpx.SortByTime().Points()
+
+	assert.Equal(t, []float64{3, 1, 2}, []float64{points[0].Latitude, points[1].Latitude, points[2].Latitude})
+}
+
+func TestShiftTime(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	result := gpx.ShiftTime(-8 * time.Hour)
+	points, shifted := gpx.Points(), result.Points()
+
+	assert.Equal(t, gpx.Duration(), result.Duration())
+	assert.Equal(t, gpx.MovingTime(), result.MovingTime())
+	assert.Len(t, shifted, len(points))
+
+	for i := range points {
+		assert.Equal(t, points[i].Time().Add(-8*time.Hour), shifted[i].Time())
+	}
+
+	assert.Equal(t, "2020-05-02T23:00:00Z", shifted[0].Timestamp)
+	assert.Equal(t, "2020-05-03T07:00:00Z", points[0].Timestamp)
+}
+
+func TestShiftTimeKeepsFormat(t *testing.T) {
+	gpx := &GPX{
+		Metadata:  &MetaData{Timestamp: "2020-05-03T07:00:00+08:00"},
+		Waypoints: []WayPoint{{Timestamp: "2020-05-03T07:00:00.250Z"}},
+		Routes:    []Route{{RoutePoints: []WayPoint{{Timestamp: "2020-05-03T07:00:00"}}}},
+		Tracks: []Track{{TrackSegments: []TrackSegment{{TrackPoint: []WayPoint{
+			{Timestamp: "2020-05-03T07:00:00.100+0800"},
+			{},
+			{Timestamp: "yesterday"},
+		}}}}},
+	}
+
+	result := gpx.ShiftTime(90 * time.Minute)
+
+	assert.Equal(t, "2020-05-03T08:30:00+08:00", result.Metadata.Timestamp)
+	assert.Equal(t, "2020-05-03T08:30:00.250Z", result.Waypoints[0].Timestamp)
+	assert.Equal(t, "2020-05-03T08:30:00", result.Routes[0].RoutePoints[0].Timestamp)
+	assert.Equal(t, "2020-05-03T08:30:00.100+0800", result.Tracks[0].TrackSegments[0].TrackPoint[0].Timestamp)
+	assert.Equal(t, "", result.Tracks[0].TrackSegments[0].TrackPoint[1].Timestamp)
+	assert.Equal(t, "yesterday", result.Tracks[0].TrackSegments[0].TrackPoint[2].Timestamp)
+	assert.Equal(t, "2020-05-03T07:00:00+08:00", gpx.Metadata.Timestamp)
+}
+
+func TestSetTimezone(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	result := gpx.SetTimezone(time.FixedZone("CST", 8*60*60))
+
+	assert.Equal(t, "2020-05-03T15:00:00+08:00", result.Metadata.Timestamp)
+	assert.Equal(t, "2020-05-03T15:00:00+08:00", result.Points()[0].Timestamp)
+	assert.Equal(t, gpx.Duration(), result.Duration())
+
+	var buf bytes.Buffer
+
+	assert.NoError(t, WriteGPX(&buf, result))
+
+	reread, err := ReadGPX(&buf)
+
+	assert.NoError(t, err)
+
+	for i, point := range gpx.Points() {
+		assert.True(t, point.Time().Equal(reread.Points()[i].Time()))
+	}
+
+	back := result.SetTimezone(time.UTC)
+
+	assert.Equal(t, gpx.Points(), back.Points())
+	assert.Equal(t, gpx.Metadata.Timestamp, back.Metadata.Timestamp)
+}
+
+func TestSetTimezoneWithoutOffset(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{{Timestamp: "2020-05-03T07:00:00.5"}})
+
+	assert.Equal(t, "2020-05-03T02:00:00.5-05:00", gpx.SetTimezone(time.FixedZone("", -5*60*60)).Points()[0].Timestamp)
+	assert.Equal(t, "2020-05-03T07:00:00.5", gpx.SetTimezone(time.UTC).Points()[0].Timestamp)
+}
@@ -0,0 +1,179 @@
+package gpx
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// ValidationError is a GPX value out of the range allowed by the schema.
+type ValidationError struct {
+	Path    string // path of the invalid value, e.g. trk[0].trkseg[1].trkpt[2].lat
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ReadGPXStrict is a GPX reader like ReadGPX which also returns the first
+// validation error of the GPX, see Validate.
+func ReadGPXStrict(r io.Reader) (*GPX, error) {
+	gpx, err := ReadGPX(r)
+
+	if err != nil {
+		return gpx, err
+	}
+
+	if errs := gpx.Validate(); len(errs) > 0 {
+		return gpx, errs[0]
+	}
+
+	return gpx, nil
+}
+
+// Validate returns every value of the GPX out of the range allowed by the
+// schema as a *ValidationError, or nil when the GPX is valid.
+func (g *GPX) Validate() []error {
+	var errs []error
+
+	for i := range g.Waypoints {
+		errs = append(errs, g.Waypoints[i].validate(fmt.Sprintf("wpt[%d]", i))...)
+	}
+
+	for i, route := range g.Routes {
+		for j := range route.RoutePoints {
+			errs = append(errs, route.RoutePoints[j].validate(fmt.Sprintf("rte[%d].rtept[%d]", i, j))...)
+		}
+	}
+
+	for i, track := range g.Tracks {
+		for j, segment := range track.TrackSegments {
+			for k := range segment.TrackPoint {
+				errs = append(errs, segment.TrackPoint[k].validate(fmt.Sprintf("trk[%d].trkseg[%d].trkpt[%d]", i, j, k))...)
+			}
+		}
+	}
+
+	return errs
+}
+
+// ValidateTimestamps returns every track point timestamp which can't be
+// parsed, is before the previous timestamp of its segment or equals it, as
+// a *ValidationError, or nil when the timestamps of every segment strictly
+// increase. Track points without timestamp are skipped, see SortByTime to
+// reorder the points.
+func (g *GPX) ValidateTimestamps() []error {
+	var errs []error
+
+	for i, track := range g.Tracks {
+		for j, segment := range track.TrackSegments {
+			var previous time.Time
+
+			for k := range segment.TrackPoint {
+				point := &segment.TrackPoint[k]
+
+				if point.Timestamp == "" {
+					continue
+				}
+
+				path := fmt.Sprintf("trk[%d].trkseg[%d].trkpt[%d].time", i, j, k)
+				t, err := point.TimeParse()
+
+				if err != nil {
+					errs = append(errs, &ValidationError{
+						Path:    path,
+						Message: fmt.Sprintf("timestamp %q can't be parsed", point.Timestamp),
+					})
+
+					continue
+				}
+
+				if !previous.IsZero() && t.Before(previous) {
+					errs = append(errs, &ValidationError{
+						Path:    path,
+						Message: fmt.Sprintf("timestamp %v before the previous one %v", t.Format(time.RFC3339Nano), previous.Format(time.RFC3339Nano)),
+					})
+				} else if !previous.IsZero() && t.Equal(previous) {
+					errs = append(errs, &ValidationError{
+						Path:    path,
+						Message: fmt.Sprintf("timestamp %v duplicates the previous one", t.Format(time.RFC3339Nano)),
+					})
+				}
+
+				previous = t
+			}
+		}
+	}
+
+	return errs
+}
+
+// validate returns the validation errors of the point at path.
+func (w *WayPoint) validate(path string) []error {
+	var errs []error
+
+	if w.Latitude < -90 || w.Latitude > 90 {
+		errs = append(errs, &ValidationError{
+			Path:    path + ".lat",
+			Message: fmt.Sprintf("latitude %v of point (%v, %v) out of range [-90, 90]", w.Latitude, w.Latitude, w.Longitude),
+		})
+	}
+
+	if w.Longitude < -180 || w.Longitude > 180 {
+		errs = append(errs, &ValidationError{
+			Path:    path + ".lon",
+			Message: fmt.Sprintf("longitude %v of point (%v, %v) out of range [-180, 180]", w.Longitude, w.Latitude, w.Longitude),
+		})
+	}
+
+	if !w.MagneticVariation.Valid() {
+		errs = append(errs, &ValidationError{
+			Path:    path + ".magvar",
+			Message: fmt.Sprintf("degrees %v out of range [0, 360)", float64(w.MagneticVariation)),
+		})
+	}
+
+	if !w.Course.Valid() {
+		errs = append(errs, &ValidationError{
+			Path:    path + ".course",
+			Message: fmt.Sprintf("degrees %v out of range [0, 360)", float64(w.Course)),
+		})
+	}
+
+	if !w.DifferentialGPSID.Valid() {
+		errs = append(errs, &ValidationError{
+			Path:    path + ".dgpsid",
+			Message: fmt.Sprintf("DGPS station %v out of range [0, 1023]", int(w.DifferentialGPSID)),
+		})
+	}
+
+	if w.Fix != "" && !w.Fix.Valid() {
+		errs = append(errs, &ValidationError{
+			Path:    path + ".fix",
+			Message: fmt.Sprintf("fix %q not one of none, 2d, 3d, dgps or pps", string(w.Fix)),
+		})
+	}
+
+	return errs
+}
+
+// Valid reports whether the Fix is one of the values allowed by the schema.
+func (f Fix) Valid() bool {
+	switch f {
+	case "none", "2d", "3d", "dgps", "pps":
+		return true
+	}
+
+	return false
+}
+
+// Valid reports whether the Degrees is in the range [0, 360) allowed by the schema.
+func (d Degrees) Valid() bool {
+	return d >= 0 && d < 360
+}
+
+// Valid reports whether the DGPSStation is in the range [0, 1023] allowed by the schema.
+func (s DGPSStation) Valid() bool {
+	return s >= 0 && s <= 1023
+}
@@ -0,0 +1,133 @@
+package gpx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	assert.Empty(t, gpx.Validate())
+}
+
+func TestValidateCoordinates(t *testing.T) {
+	b := openGPX("_data/invalid-coordinates.gpx")
+	gpx, _ := ReadGPX(b)
+
+	errs := gpx.Validate()
+
+	assert.Len(t, errs, 2)
+	assert.Equal(t, "wpt[0].lat: latitude 95 of point (95, 121.5) out of range [-90, 90]", errs[0].Error())
+	assert.Equal(t, "trk[0].trkseg[0].trkpt[1].lon: longitude 999 of point (25.001, 999) out of range [-180, 180]", errs[1].Error())
+	assert.Equal(t, "trk[0].trkseg[0].trkpt[1].lon", errs[1].(*ValidationError).Path)
+}
+
+func TestValidateRoutePoints(t *testing.T) {
+	gpx := &GPX{Routes: []Route{{RoutePoints: []WayPoint{{}, {Latitude: -91, Longitude: -181}}}}}
+
+	errs := gpx.Validate()
+
+	assert.Len(t, errs, 2)
+	assert.Equal(t, "rte[0].rtept[1].lat", errs[0].(*ValidationError).Path)
+	assert.Equal(t, "rte[0].rtept[1].lon", errs[1].(*ValidationError).Path)
+}
+
+func TestReadGPXStrict(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, err := ReadGPXStrict(b)
+
+	assert.NoError(t, err)
+	assert.Len(t, gpx.Points(), 15)
+
+	b = openGPX("_data/invalid-coordinates.gpx")
+	_, err = ReadGPXStrict(b)
+
+	assert.Error(t, err)
+	assert.IsType(t, &ValidationError{}, err)
+	assert.Equal(t, "wpt[0].lat", err.(*ValidationError).Path)
+}
+
+func TestFixValid(t *testing.T) {
+	for _, fix := range []Fix{"none", "2d", "3d", "dgps", "pps"} {
+		assert.True(t, fix.Valid(), string(fix))
+	}
+
+	for _, fix := range []Fix{"", "4d", "3D", "gps"} {
+		assert.False(t, fix.Valid(), string(fix))
+	}
+}
+
+func TestValidateFix(t *testing.T) {
+	gpx := &GPX{Waypoints: []WayPoint{{Fix: "3d"}, {Fix: "4d"}, {}}}
+
+	errs := gpx.Validate()
+
+	assert.Len(t, errs, 1)
+	assert.Equal(t, `wpt[1].fix: fix "4d" not one of none, 2d, 3d, dgps or pps`, errs[0].Error())
+}
+
+func TestDegreesValid(t *testing.T) {
+	assert.True(t, Degrees(0).Valid())
+	assert.True(t, Degrees(359.99).Valid())
+	assert.False(t, Degrees(360).Valid())
+	assert.False(t, Degrees(400).Valid())
+	assert.False(t, Degrees(-1).Valid())
+}
+
+func TestDGPSStationValid(t *testing.T) {
+	assert.True(t, DGPSStation(0).Valid())
+	assert.True(t, DGPSStation(1023).Valid())
+	assert.False(t, DGPSStation(1024).Valid())
+	assert.False(t, DGPSStation(-1).Valid())
+}
+
+func TestValidateDegreesAndDGPSStation(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{MagneticVariation: 12},
+		{MagneticVariation: 400, Course: 360, DifferentialGPSID: 2048},
+	})
+
+	errs := gpx.Validate()
+
+	assert.Len(t, errs, 3)
+	assert.Equal(t, "trk[0].trkseg[0].trkpt[1].magvar: degrees 400 out of range [0, 360)", errs[0].Error())
+	assert.Equal(t, "trk[0].trkseg[0].trkpt[1].course: degrees 360 out of range [0, 360)", errs[1].Error())
+	assert.Equal(t, "trk[0].trkseg[0].trkpt[1].dgpsid: DGPS station 2048 out of range [0, 1023]", errs[2].Error())
+}
+
+func TestValidateTimestamps(t *testing.T) {
+	b := openGPX("_data/out-of-order.gpx")
+	gpx, _ := ReadGPX(b)
+
+	errs := gpx.ValidateTimestamps()
+
+	assert.Len(t, errs, 2)
+	assert.Equal(t, "trk[0].trkseg[0].trkpt[2].time: timestamp 2020-05-03T07:00:30Z before the previous one 2020-05-03T07:01:00Z", errs[0].Error())
+	assert.Equal(t, "trk[0].trkseg[0].trkpt[4].time: timestamp 2020-05-03T07:01:30Z duplicates the previous one", errs[1].Error())
+	assert.Empty(t, gpx.SortByTime().RemoveDuplicates().ValidateTimestamps())
+}
+
+func TestValidateTimestampsUnparseable(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{
+		{Timestamp: "2020-05-03T07:00:00Z"},
+		{},
+		{Timestamp: "yesterday"},
+		{Timestamp: "2020-05-03T07:00:10Z"},
+	})
+
+	errs := gpx.ValidateTimestamps()
+
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "trk[0].trkseg[0].trkpt[2].time", errs[0].(*ValidationError).Path)
+}
+
+func TestValidateTimestampsValid(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	assert.Empty(t, gpx.ValidateTimestamps())
+	assert.Empty(t, (&GPX{}).ValidateTimestamps())
+}
@@ -0,0 +1,417 @@
+package gpx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"math"
+	"sort"
+)
+
+const (
+	// GPXNamespace is the GPX 1.1 XML namespace.
+	GPXNamespace = "http://www.topografix.com/GPX/1/1"
+
+	// XSINamespace is the XML Schema instance namespace.
+	XSINamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+	// GPXSchemaLocation is the GPX 1.1 schema location.
+	GPXSchemaLocation = "http://www.topografix.com/GPX/1/1 http://www.topografix.com/GPX/1/1/gpx.xsd"
+
+	// TrackPointExtensionNamespace is the Garmin TrackPointExtension v1 namespace.
+	TrackPointExtensionNamespace = "http://www.garmin.com/xmlschemas/TrackPointExtension/v1"
+
+	// TrackPointExtensionV2Namespace is the Garmin TrackPointExtension v2 namespace.
+	TrackPointExtensionV2Namespace = "http://www.garmin.com/xmlschemas/TrackPointExtension/v2"
+
+	// GpxExtensionsNamespace is the Garmin GpxExtensions v3 namespace.
+	GpxExtensionsNamespace = "http://www.garmin.com/xmlschemas/GpxExtensions/v3"
+
+	// DefaultCreator is written as the creator attribute when the GPX has none.
+	DefaultCreator = "github.com/neighborhood999/gpx"
+)
+
+// WriteOptions configures how WriteGPXOptions writes a GPX object.
+type WriteOptions struct {
+	// Indent indents every nested element on a new line when not empty.
+	Indent string
+
+	// Precision is the number of decimal places latitudes and longitudes
+	// are rounded to, FullPrecision keeps them as they are. It is a pointer,
+	// set with Precision, so that nil means DefaultPrecision while 0 rounds
+	// them to whole degrees.
+	Precision *int
+
+	// Bounds writes the bounds element in the metadata, computed by Bounds
+	// from the written coordinates. The bounds of the GPX are replaced.
+	Bounds bool
+}
+
+const (
+	// DefaultPrecision is the default number of decimal places of written
+	// coordinates, about 1cm.
+	DefaultPrecision = 7
+
+	// FullPrecision writes coordinates without rounding them.
+	FullPrecision = -1
+)
+
+// Precision returns a pointer to the number of decimal places, to be used as
+// the Precision of WriteOptions.
+func Precision(decimals int) *int {
+	return &decimals
+}
+
+// WriteGPX writes the GPX object to w as a GPX 1.1 document.
+func WriteGPX(w io.Writer, g *GPX) error {
+	return WriteGPXOptions(w, g, WriteOptions{Precision: Precision(FullPrecision)})
+}
+
+// WriteGPXBytes returns the GPX object as a GPX 1.1 document like WriteGPX.
+func WriteGPXBytes(g *GPX) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := WriteGPX(&buf, g); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// WriteGPXIndent writes the GPX object to w as an indented GPX 1.1 document,
+// every nested element begins on a new line indented by indent.
+func WriteGPXIndent(w io.Writer, g *GPX, indent string) error {
+	return WriteGPXOptions(w, g, WriteOptions{Indent: indent, Precision: Precision(FullPrecision)})
+}
+
+// WriteGPXOptions writes the GPX object to w as a GPX 1.1 document
+// configured by opts.
+func WriteGPXOptions(w io.Writer, g *GPX, opts WriteOptions) error {
+	precision := DefaultPrecision
+
+	if opts.Precision != nil {
+		precision = *opts.Precision
+	}
+
+	if precision >= 0 {
+		g = g.roundCoordinates(precision)
+	}
+
+	if opts.Bounds {
+		g = g.withBounds()
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	e := xml.NewEncoder(w)
+	e.Indent("", opts.Indent)
+
+	if err := e.EncodeElement(rootGPX(g), rootStartElement(g)); err != nil {
+		return err
+	}
+
+	return e.Flush()
+}
+
+// roundCoordinates returns a copy of the GPX with every latitude and
+// longitude rounded to precision decimal places.
+func (g *GPX) roundCoordinates(precision int) *GPX {
+	factor := math.Pow10(precision)
+	round := func(value float64) float64 {
+		return math.Round(value*factor) / factor
+	}
+
+	rounded := g.Clone()
+	roundPoints := func(points []WayPoint) {
+		for i := range points {
+			points[i].Latitude = round(points[i].Latitude)
+			points[i].Longitude = round(points[i].Longitude)
+		}
+	}
+
+	roundPoints(rounded.Waypoints)
+
+	for _, route := range rounded.Routes {
+		roundPoints(route.RoutePoints)
+	}
+
+	for _, track := range rounded.Tracks {
+		for _, segment := range track.TrackSegments {
+			roundPoints(segment.TrackPoint)
+		}
+	}
+
+	if rounded.Metadata != nil && rounded.Metadata.Bounds != nil {
+		bounds := rounded.Metadata.Bounds
+		bounds.MinLatitude = round(bounds.MinLatitude)
+		bounds.MinLongitude = round(bounds.MinLongitude)
+		bounds.MaxLatitude = round(bounds.MaxLatitude)
+		bounds.MaxLongitude = round(bounds.MaxLongitude)
+	}
+
+	return rounded
+}
+
+// withBounds returns a shallow copy of the GPX with the metadata bounds set
+// to its bounding box, or g itself when it has no point.
+func (g *GPX) withBounds() *GPX {
+	minLat, minLon, maxLat, maxLon, ok := g.Bounds()
+
+	if !ok {
+		return g
+	}
+
+	result := *g
+	metadata := MetaData{}
+
+	if g.Metadata != nil {
+		metadata = *g.Metadata
+	}
+
+	metadata.Bounds = &Bounds{MinLatitude: minLat, MinLongitude: minLon, MaxLatitude: maxLat, MaxLongitude: maxLon}
+	result.Metadata = &metadata
+
+	return &result
+}
+
+// rootGPX returns a shallow copy of g with the attributes required by GPX 1.1.
+func rootGPX(g *GPX) *GPX {
+	root := *g
+	root.Version = "1.1"
+
+	if root.Creator == "" {
+		root.Creator = DefaultCreator
+	}
+
+	return &root
+}
+
+// rootStartElement returns the <gpx> start element with its namespace
+// declarations, including the Namespaces of g and the ones of the prefixes
+// used by its extensions.
+func rootStartElement(g *GPX) xml.StartElement {
+	start := xml.StartElement{
+		Name: xml.Name{Local: "gpx"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "xmlns"}, Value: GPXNamespace},
+			{Name: xml.Name{Local: "xmlns:xsi"}, Value: XSINamespace},
+			{Name: xml.Name{Local: "xsi:schemaLocation"}, Value: GPXSchemaLocation},
+		},
+	}
+
+	namespaces := g.extensionNamespaces()
+
+	for prefix, namespace := range g.Namespaces {
+		if prefix != "" && prefix != "xsi" {
+			namespaces[prefix] = namespace
+		}
+	}
+
+	prefixes := make([]string, 0, len(namespaces))
+
+	for prefix := range namespaces {
+		prefixes = append(prefixes, prefix)
+	}
+
+	sort.Strings(prefixes)
+
+	for _, prefix := range prefixes {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:" + prefix}, Value: namespaces[prefix]})
+	}
+
+	return start
+}
+
+// wellKnownNamespaces are the namespaces of the extension prefixes commonly
+// used by devices and applications.
+var wellKnownNamespaces = map[string]string{
+	"gpxtpx": TrackPointExtensionNamespace,
+	"gpxx":   GpxExtensionsNamespace,
+	"gpxtrx": "http://www.garmin.com/xmlschemas/TripExtensions/v1",
+	"wptx1":  "http://www.garmin.com/xmlschemas/WaypointExtension/v1",
+	"ctx":    "http://www.garmin.com/xmlschemas/CreationTimeExtension/v1",
+	"pwr":    "http://www.garmin.com/xmlschemas/PowerExtension/v1",
+}
+
+// extensionNamespaces returns the namespaces of the prefixes used but not
+// declared by the raw extensions of the routes, tracks and track segments.
+// The extensions only keep their inner XML, so the namespace of a prefix
+// comes from the Namespaces of g or else from wellKnownNamespaces, and
+// unknown prefixes are left out.
+func (g *GPX) extensionNamespaces() map[string]string {
+	namespaces := map[string]string{}
+	add := func(extensions *Extensions) {
+		if extensions == nil {
+			return
+		}
+
+		for _, prefix := range undeclaredPrefixes(extensions.XML) {
+			if namespace, ok := g.Namespaces[prefix]; ok {
+				namespaces[prefix] = namespace
+			} else if namespace, ok := wellKnownNamespaces[prefix]; ok {
+				namespaces[prefix] = namespace
+			}
+		}
+	}
+
+	for i := range g.Routes {
+		add(g.Routes[i].Extensions)
+	}
+
+	for i := range g.Tracks {
+		add(g.Tracks[i].Extensions)
+
+		for j := range g.Tracks[i].TrackSegments {
+			add(g.Tracks[i].TrackSegments[j].Extensions)
+		}
+	}
+
+	return namespaces
+}
+
+// undeclaredPrefixes returns the namespace prefixes of the elements and
+// attributes of the raw XML which are not declared in the XML itself.
+func undeclaredPrefixes(raw []byte) []string {
+	var prefixes []string
+
+	used := map[string]bool{}
+	declared := map[string]bool{"xml": true, "xmlns": true, "xsi": true}
+	d := xml.NewDecoder(bytes.NewReader(raw))
+
+	for {
+		token, err := d.RawToken()
+
+		if err != nil {
+			break
+		}
+
+		start, ok := token.(xml.StartElement)
+
+		if !ok {
+			continue
+		}
+
+		names := []xml.Name{start.Name}
+
+		for _, attr := range start.Attr {
+			if attr.Name.Space == "xmlns" {
+				declared[attr.Name.Local] = true
+			} else {
+				names = append(names, attr.Name)
+			}
+		}
+
+		for _, name := range names {
+			if name.Space != "" && !used[name.Space] {
+				used[name.Space] = true
+				prefixes = append(prefixes, name.Space)
+			}
+		}
+	}
+
+	undeclared := prefixes[:0]
+
+	for _, prefix := range prefixes {
+		if !declared[prefix] {
+			undeclared = append(undeclared, prefix)
+		}
+	}
+
+	return undeclared
+}
+
+// MarshalXML writes the TrackPointExtension in its own namespace, keeping the
+// namespace it was read with.
+func (t *TrackPointExtension) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type trackPointExtension TrackPointExtension
+
+	start.Name.Space = t.XMLName.Space
+
+	if start.Name.Space == "" {
+		start.Name.Space = TrackPointExtensionNamespace
+	}
+
+	return e.EncodeElement((*trackPointExtension)(t), start)
+}
+
+// MarshalXML writes the point with its ele element whenever HasElevation
+// reports an elevation, so an elevation of 0 set by ElevationSet is kept.
+// Course and Speed are written as the course and speed elements of the
+// TrackPointExtension v2, the GPX 1.0 elements aren't valid in GPX 1.1.
+func (w WayPoint) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type wayPoint WayPoint
+
+	point := struct {
+		Elevation *float64 `xml:"ele,omitempty"`
+		*wayPoint
+		Extensions *pointExtensions `xml:"extensions,omitempty"`
+	}{wayPoint: (*wayPoint)(&w), Extensions: w.extensions()}
+
+	w.Course, w.Speed = 0, 0
+
+	if w.HasElevation() {
+		point.Elevation = &w.Elevation
+	}
+
+	return e.EncodeElement(point, start)
+}
+
+// extensions returns the extensions element written for the point, with
+// Course and Speed in its TrackPointExtension, or nil when the point has
+// neither Extensions nor Course and Speed.
+func (w *WayPoint) extensions() *pointExtensions {
+	if w.Extensions == nil && w.Course == 0 && w.Speed == 0 {
+		return nil
+	}
+
+	extensions := &pointExtensions{}
+	var trackPointExtension *TrackPointExtension
+
+	if w.Extensions != nil {
+		extensions.XMLName = w.Extensions.XMLName
+		trackPointExtension = w.Extensions.TrackPointExtensions
+	}
+
+	if trackPointExtension == nil && w.Course == 0 && w.Speed == 0 {
+		return extensions
+	}
+
+	extension := &pointTrackPointExtension{Speed: w.Speed, Course: w.Course}
+
+	if trackPointExtension != nil {
+		extension.XMLName = trackPointExtension.XMLName
+		extension.Temperature = trackPointExtension.Temperature
+		extension.WTemperature = trackPointExtension.WTemperature
+		extension.Depth = trackPointExtension.Depth
+		extension.HeartRate = trackPointExtension.HeartRate
+		extension.Cadence = trackPointExtension.Cadence
+	}
+
+	// The speed and course elements only exist in the v2 schema.
+	space := extension.XMLName.Space
+
+	if space == "" {
+		space = TrackPointExtensionNamespace
+	}
+
+	if (w.Course != 0 || w.Speed != 0) && space == TrackPointExtensionNamespace {
+		space = TrackPointExtensionV2Namespace
+	}
+
+	extension.XMLName = xml.Name{Space: space, Local: "TrackPointExtension"}
+	extensions.TrackPointExtension = extension
+
+	return extensions
+}
+
+// MarshalXML writes the Fix, or nothing when it isn't one of the values
+// allowed by the schema.
+func (f Fix) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if !f.Valid() {
+		return nil
+	}
+
+	return e.EncodeElement(string(f), start)
+}
@@ -0,0 +1,383 @@
+package gpx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteGPX(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	var buf bytes.Buffer
+	err := WriteGPX(&buf, gpx)
+
+	assert.NoError(t, err)
+
+	output := buf.String()
+
+	assert.True(t, strings.HasPrefix(output, `<?xml version="1.0" encoding="UTF-8"?>`))
+	assert.Contains(t, output, `xmlns="http://www.topografix.com/GPX/1/1"`)
+	assert.Contains(t, output, `xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"`)
+	assert.Contains(t, output, `xsi:schemaLocation="http://www.topografix.com/GPX/1/1 http://www.topografix.com/GPX/1/1/gpx.xsd"`)
+	assert.Contains(t, output, `creator="StravaGPX"`)
+	assert.Contains(t, output, `version="1.1"`)
+}
+
+func TestWriteGPXRoundTrip(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	var buf bytes.Buffer
+	err := WriteGPX(&buf, gpx)
+
+	assert.NoError(t, err)
+
+	reread, err := ReadGPX(&buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, gpx.Creator, reread.Creator)
+	assert.Equal(t, gpx.Version, reread.Version)
+	assert.Equal(t, gpx.Metadata.Timestamp, reread.Metadata.Timestamp)
+	assert.Equal(t, gpx.Tracks[0].Name, reread.Tracks[0].Name)
+	assert.Equal(t, gpx.Tracks[0].Type, reread.Tracks[0].Type)
+	assert.Equal(t, gpx.Tracks[0].TrackSegments[0].TrackPoint, reread.Tracks[0].TrackSegments[0].TrackPoint)
+	assert.Equal(t, gpx.Duration(), reread.Duration())
+	assert.Equal(t, gpx.Distance(), reread.Distance())
+}
+
+func TestWriteGPXBytes(t *testing.T) {
+	b := openGPX("_data/garmin-extensions.gpx")
+	gpx, _ := ReadGPX(b)
+
+	output, err := WriteGPXBytes(gpx)
+
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(output, []byte(xml.Header+`<gpx xmlns="http://www.topografix.com/GPX/1/1"`)))
+
+	var buf bytes.Buffer
+
+	assert.NoError(t, WriteGPX(&buf, gpx))
+	assert.Equal(t, buf.Bytes(), output)
+
+	reread, err := ReadGPXBytes(output)
+
+	assert.NoError(t, err)
+	assert.Equal(t, gpx.Creator, reread.Creator)
+	assert.Equal(t, gpx.Metadata.Timestamp, reread.Metadata.Timestamp)
+	assert.Equal(t, gpx.Namespaces, reread.Namespaces)
+	assert.Equal(t, gpx.Tracks[0].Name, reread.Tracks[0].Name)
+	assert.Equal(t, gpx.Tracks[0].Extensions, reread.Tracks[0].Extensions)
+	assert.Equal(t, gpx.Points(), reread.Points())
+}
+
+func TestWriteGPXDefaultCreator(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteGPX(&buf, &GPX{})
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `creator="github.com/neighborhood999/gpx"`)
+}
+
+func TestWriteGPXWaypoints(t *testing.T) {
+	b := openGPX("_data/waypoints.gpx")
+	gpx, _ := ReadGPX(b)
+
+	var buf bytes.Buffer
+	err := WriteGPX(&buf, gpx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(buf.String(), "<wpt "))
+
+	reread, err := ReadGPX(&buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, gpx.Waypoints, reread.Waypoints)
+}
+
+func TestWriteGPXMetadata(t *testing.T) {
+	b := openGPX("_data/metadata.gpx")
+	gpx, _ := ReadGPX(b)
+
+	var buf bytes.Buffer
+	err := WriteGPX(&buf, gpx)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `</author><copyright author="OpenStreetMap contributors"><year>2020</year><license>https://opendatacommons.org/licenses/odbl/</license></copyright><link `)
+
+	reread, err := ReadGPX(&buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, gpx.Metadata, reread.Metadata)
+}
+
+func TestWriteGPXTrackPointExtensionNamespace(t *testing.T) {
+	b := openGPX("_data/garmin-tpx.gpx")
+	gpx, _ := ReadGPX(b)
+
+	var buf bytes.Buffer
+	err := WriteGPX(&buf, gpx)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `<TrackPointExtension xmlns="http://www.garmin.com/xmlschemas/TrackPointExtension/v1">`)
+	assert.Contains(t, buf.String(), `<TrackPointExtension xmlns="http://www.garmin.com/xmlschemas/TrackPointExtension/v2">`)
+
+	reread, err := ReadGPX(&buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, gpx.Points(), reread.Points())
+}
+
+func TestWriteGPXExtensionNamespaces(t *testing.T) {
+	b := openGPX("_data/garmin-extensions.gpx")
+	gpx, _ := ReadGPX(b)
+
+	var buf bytes.Buffer
+	err := WriteGPX(&buf, gpx)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `xmlns:gpxx="http://www.garmin.com/xmlschemas/GpxExtensions/v3"`)
+	assert.Contains(t, buf.String(), `<gpxx:DisplayColor>Red</gpxx:DisplayColor>`)
+
+	spaces := map[string]string{}
+	d := xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+
+	for {
+		token, err := d.Token()
+
+		if err != nil {
+			break
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			spaces[start.Name.Local] = start.Name.Space
+		}
+	}
+
+	assert.Equal(t, GpxExtensionsNamespace, spaces["TrackExtension"])
+	assert.Equal(t, GpxExtensionsNamespace, spaces["DisplayColor"])
+	assert.Equal(t, TrackPointExtensionNamespace, spaces["TrackPointExtension"])
+
+	reread, err := ReadGPX(&buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, gpx.Tracks[0].Extensions, reread.Tracks[0].Extensions)
+	assert.Equal(t, gpx.Points(), reread.Points())
+}
+
+func TestWriteGPXNamespaces(t *testing.T) {
+	gpx := &GPX{
+		Namespaces: map[string]string{"": GPXNamespace, "xsi": XSINamespace, "power": "http://www.garmin.com/xmlschemas/PowerExtension/v1"},
+		Tracks:     []Track{{Extensions: &Extensions{XML: []byte("<power:PowerInWatts>200</power:PowerInWatts>")}}},
+	}
+
+	var buf bytes.Buffer
+	err := WriteGPX(&buf, gpx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(buf.String(), "xmlns="))
+	assert.Equal(t, 1, strings.Count(buf.String(), "xmlns:xsi="))
+	assert.Contains(t, buf.String(), `xmlns:power="http://www.garmin.com/xmlschemas/PowerExtension/v1"`)
+
+	reread, err := ReadGPX(&buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, gpx.Namespaces, reread.Namespaces)
+}
+
+func TestUndeclaredPrefixes(t *testing.T) {
+	raw := []byte(`<gpxx:TrackExtension gpxx:a="1"><p:b xmlns:p="urn:p"/><c/><ctx:d/></gpxx:TrackExtension>`)
+
+	assert.Equal(t, []string{"gpxx", "ctx"}, undeclaredPrefixes(raw))
+	assert.Empty(t, undeclaredPrefixes(nil))
+}
+
+func TestWriteGPXFix(t *testing.T) {
+	gpx := &GPX{Waypoints: []WayPoint{{Fix: "3d"}, {Fix: "4d"}, {}}}
+
+	var buf bytes.Buffer
+	err := WriteGPX(&buf, gpx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(buf.String(), "<fix>"))
+	assert.Contains(t, buf.String(), "<fix>3d</fix>")
+	assert.NotContains(t, buf.String(), "4d")
+}
+
+func TestWriteGPXAgeOfDGPSData(t *testing.T) {
+	gpx := &GPX{Waypoints: []WayPoint{{AgeOfGpsData: 2.5, DifferentialGPSID: 12}}}
+
+	var buf bytes.Buffer
+	err := WriteGPX(&buf, gpx)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "<ageofdgpsdata>2.5</ageofdgpsdata><dgpsid>12</dgpsid>")
+
+	reread, err := ReadGPX(&buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2.5, reread.Waypoints[0].AgeOfGpsData)
+}
+
+func TestWriteGPXSpeedAndCourse(t *testing.T) {
+	b := openGPX("_data/gpx10.gpx")
+	gpx, _ := ReadGPX(b)
+
+	var buf bytes.Buffer
+	err := WriteGPX(&buf, gpx)
+
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "<course>0.5</course><speed>5.6</speed>")
+	assert.Contains(t, buf.String(), `<time>2008-06-01T06:00:20Z</time><extensions><TrackPointExtension xmlns="http://www.garmin.com/xmlschemas/TrackPointExtension/v2"><speed>5.6</speed><course>0.5</course></TrackPointExtension></extensions></trkpt>`)
+
+	reread, err := ReadGPX(&buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, gpx.Points(), reread.Points())
+	assert.Equal(t, 5.4, reread.Points()[2].Speed)
+	assert.Equal(t, Degrees(1), reread.Points()[2].Course)
+	assert.Nil(t, reread.Points()[2].Extensions)
+}
+
+func TestWriteGPXSpeedAndCourseWithTrackPointExtension(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{{
+		Course:     90,
+		Speed:      3.2,
+		Extensions: &TrackPointExtensions{TrackPointExtensions: &TrackPointExtension{HeartRate: 140, Cadence: 85}},
+	}})
+
+	var buf bytes.Buffer
+	err := WriteGPX(&buf, gpx)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `<trkpt lat="0" lon="0"><extensions><TrackPointExtension xmlns="http://www.garmin.com/xmlschemas/TrackPointExtension/v2"><hr>140</hr><cad>85</cad><speed>3.2</speed><course>90</course></TrackPointExtension></extensions></trkpt>`)
+
+	reread, err := ReadGPX(&buf)
+
+	assert.NoError(t, err)
+
+	point := reread.Points()[0]
+
+	assert.Equal(t, Degrees(90), point.Course)
+	assert.Equal(t, 3.2, point.Speed)
+	assert.Equal(t, 140, point.Extensions.TrackPointExtensions.HeartRate)
+	assert.Equal(t, TrackPointExtensionV2Namespace, point.Extensions.TrackPointExtensions.XMLName.Space)
+	assert.Equal(t, Degrees(90), gpx.Points()[0].Course)
+	assert.Equal(t, TrackPointExtensions{TrackPointExtensions: &TrackPointExtension{HeartRate: 140, Cadence: 85}}, *gpx.Points()[0].Extensions)
+}
+
+func TestWriteGPXMissingElevation(t *testing.T) {
+	b := openGPX("_data/missing-elevation.gpx")
+	gpx, _ := ReadGPX(b)
+
+	var buf bytes.Buffer
+	err := WriteGPX(&buf, gpx)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `<trkpt lat="25" lon="121.5"><ele>0</ele><time>`)
+	assert.Contains(t, buf.String(), `<trkpt lat="25.001" lon="121.5"><time>`)
+
+	reread, err := ReadGPX(&buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, gpx.Points(), reread.Points())
+}
+
+func TestWriteGPXIndent(t *testing.T) {
+	b := openGPX(testGPX)
+	gpx, _ := ReadGPX(b)
+
+	var buf bytes.Buffer
+	err := WriteGPXIndent(&buf, gpx, "  ")
+
+	assert.NoError(t, err)
+
+	output := buf.String()
+
+	assert.True(t, strings.HasPrefix(output, xml.Header+`<gpx xmlns="http://www.topografix.com/GPX/1/1"`))
+	assert.Contains(t, output, "\n  <metadata>\n    <time>2019-10-26T21:21:11Z</time>\n  </metadata>\n")
+	assert.Contains(t, output, "\n      <trkpt lat=\"25.039374\" lon=\"121.516609\">\n        <ele>15.4</ele>\n")
+
+	reread, err := ReadGPX(&buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, gpx.Points(), reread.Points())
+	assert.Equal(t, gpx.Metadata.Timestamp, reread.Metadata.Timestamp)
+}
+
+func TestWriteGPXOptionsPrecision(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{{Latitude: 25.03937412345678, Longitude: 121.51660987654321}})
+	gpx.Metadata = &MetaData{Bounds: &Bounds{MinLatitude: 25.03937412345678}}
+
+	var buf bytes.Buffer
+	err := WriteGPXOptions(&buf, gpx, WriteOptions{})
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `<trkpt lat="25.0393741" lon="121.5166099">`)
+	assert.Contains(t, buf.String(), `minlat="25.0393741"`)
+
+	reread, err := ReadGPX(&buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 25.0393741, reread.Points()[0].Latitude)
+	assert.Equal(t, 121.5166099, reread.Points()[0].Longitude)
+	assert.Equal(t, 25.03937412345678, gpx.Points()[0].Latitude)
+
+	buf.Reset()
+	err = WriteGPXOptions(&buf, gpx, WriteOptions{Precision: Precision(3), Indent: "\t"})
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "\t\t\t<trkpt lat=\"25.039\" lon=\"121.517\">")
+}
+
+func TestWriteGPXOptionsZeroPrecision(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{{Latitude: 25.53937412345678, Longitude: 121.31660987654321}})
+
+	var buf bytes.Buffer
+	err := WriteGPXOptions(&buf, gpx, WriteOptions{Precision: Precision(0)})
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `<trkpt lat="26" lon="121">`)
+}
+
+func TestWriteGPXOptionsFullPrecision(t *testing.T) {
+	gpx := newTestGPX([]WayPoint{{Latitude: 25.03937412345678, Longitude: 121.51660987654321}})
+
+	var buf bytes.Buffer
+	err := WriteGPXOptions(&buf, gpx, WriteOptions{Precision: Precision(FullPrecision)})
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `<trkpt lat="25.03937412345678" lon="121.51660987654321">`)
+}
+
+func TestWriteGPXOptionsBounds(t *testing.T) {
+	b := openGPX("_data/two-segments.gpx")
+	gpx, _ := ReadGPX(b)
+
+	var buf bytes.Buffer
+	err := WriteGPXOptions(&buf, gpx, WriteOptions{Bounds: true})
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `<bounds minlat="25" minlon="121.5" maxlat="25.012" maxlon="121.5"></bounds>`)
+	assert.Nil(t, gpx.Metadata.Bounds)
+
+	reread, err := ReadGPX(&buf)
+	minLat, minLon, maxLat, maxLon, _ := gpx.Bounds()
+
+	assert.NoError(t, err)
+	assert.Equal(t, &Bounds{MinLatitude: minLat, MinLongitude: minLon, MaxLatitude: maxLat, MaxLongitude: maxLon}, reread.Metadata.Bounds)
+
+	buf.Reset()
+
+	assert.NoError(t, WriteGPXOptions(&buf, gpx, WriteOptions{}))
+	assert.NotContains(t, buf.String(), "<bounds")
+
+	buf.Reset()
+
+	assert.NoError(t, WriteGPXOptions(&buf, &GPX{}, WriteOptions{Bounds: true}))
+	assert.NotContains(t, buf.String(), "<bounds")
+}